@@ -0,0 +1,80 @@
+package goregex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAtomicAndPossessive(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		want    []int
+	}{
+		{`a*+a`, "aaa", nil},
+		{`a*+b`, "aaab", []int{0, 4}},
+		{`a++a`, "aaa", nil},
+		{`a?+a`, "a", nil},
+		{`a?+b`, "ab", []int{0, 2}},
+		{`a{1,2}+a`, "aaa", []int{0, 3}},
+		{`a{1,2}+a`, "aa", nil},
+		{`\d++\.`, "x123.", []int{1, 5}},
+		{`"[^"]*+"`, `say "hi" now`, []int{4, 8}},
+		{`(?>a|ab)c`, "abc", nil},
+		{`(?:a|ab)c`, "abc", []int{0, 3}},
+		{`(?>a+)b`, "aaab", []int{0, 4}},
+		{`(?>a+)a`, "aaaa", nil},
+		{`(?>(a+))(b)`, "aab", []int{0, 3, 0, 2, 2, 3}},
+		{`(?>x*)*y`, "xxy", []int{0, 3}},
+		{`(?>a|b)*+c`, "abbac", []int{0, 5}},
+		{`(?>(?=a)\w)+`, "aab", []int{0, 2}},
+	}
+	for _, test := range tests {
+		for _, e := range engines {
+			re, err := Compile(test.pattern)
+			if err != nil {
+				t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+			}
+			if err := re.SetEngine(e.engine); err != nil {
+				if e.engine != EnginePikeVM {
+					t.Errorf("%s: SetEngine for %q: %s", e.name, test.pattern, err.Render())
+				}
+				continue
+			}
+			if got := re.FindSubmatchIndex(test.input); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s: %q.FindSubmatchIndex(%q) = %v, want %v", e.name, test.pattern, test.input, got, test.want)
+			}
+		}
+	}
+}
+
+// the backtracking matcher used to recurse once per character and overflow the stack,
+// and to try the same failing paths over and over
+func TestBacktrackLongInputs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		want    []int
+	}{
+		{`^a*+b`, strings.Repeat("a", 500000), nil},
+		{`^(?>a|b)*$`, strings.Repeat("ab", 250000), []int{0, 500000}},
+		{`^(a)\1*b`, strings.Repeat("a", 500000), nil},
+		{`(?>x)(a|a)*c`, "x" + strings.Repeat("a", 40), nil},
+		{`(?>x)(?:a|aa)*c`, "x" + strings.Repeat("a", 40), nil},
+	}
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+		}
+		start := time.Now()
+		if got := re.FindIndex(test.input); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q.FindIndex(%d bytes) = %v, want %v", test.pattern, len(test.input), got, test.want)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%q on %d bytes took %v", test.pattern, len(test.input), elapsed)
+		}
+	}
+}
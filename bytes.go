@@ -0,0 +1,86 @@
+package goregex
+
+// the byte slice counterparts of the string functions, the input is read in place
+// and what they return are slices of it, capped so that appending to them can't overwrite it.
+// with Latin1 the input is read one byte per character instead of as UTF-8
+
+// Match reports whether the pattern matches anywhere in 'b', MatchString for a byte slice
+func (re *Regexp) Match(b []byte) bool {
+	if re.latin1 {
+		return matches(re.prog, latin1Bytes(b))
+	}
+	return matches(re.prog, b)
+}
+
+// Find returns the leftmost match in 'b', nil when there's none
+func (re *Regexp) Find(b []byte) []byte {
+	caps := re.firstBytesMatch(b)
+	if caps == nil {
+		return nil
+	}
+	return b[caps[0]:caps[1]:caps[1]]
+}
+
+// FindAll returns the successive non overlapping matches in 'b', at most 'n' of them
+// unless 'n' is negative. nil when there's no match
+func (re *Regexp) FindAll(b []byte, n int) [][]byte {
+	var results [][]byte
+	re.allBytesMatches(b, n, func(caps []int) {
+		results = append(results, b[caps[0]:caps[1]:caps[1]])
+	})
+	return results
+}
+
+// FindSubmatch returns the leftmost match in 'b' followed by what its groups matched,
+// nil for the groups that didn't take part in the match. nil when there's no match
+func (re *Regexp) FindSubmatch(b []byte) [][]byte {
+	caps := re.firstBytesMatch(b)
+	if caps == nil {
+		return nil
+	}
+	groups := make([][]byte, len(caps)/2)
+	for i := range groups {
+		if start, end := caps[2*i], caps[2*i+1]; start >= 0 && end >= start {
+			groups[i] = b[start:end:end]
+		}
+	}
+	return groups
+}
+
+// ReplaceAll returns a copy of 'src' with every match replaced by 'repl' as is,
+// ReplaceAllString for byte slices
+func (re *Regexp) ReplaceAll(src []byte, repl []byte) []byte {
+	var out []byte
+	lastMatchEnd := 0
+	re.allBytesMatches(src, -1, func(caps []int) {
+		out = append(out, src[lastMatchEnd:caps[0]]...)
+		out = append(out, repl...)
+		lastMatchEnd = caps[1]
+	})
+	return append(out, src[lastMatchEnd:]...)
+}
+
+// the capture slots of the leftmost match in 'b', nil when there's none
+func (re *Regexp) firstBytesMatch(b []byte) []int {
+	if re.latin1 {
+		return firstMatch(re.prog, latin1Bytes(b))
+	}
+	return firstMatch(re.prog, b)
+}
+
+// the leftmost match, after asking the lazy dfa whether there can be one
+func firstMatch[T inputText](p *program, input T) []int {
+	if ruledOut(p, input) {
+		return nil
+	}
+	return match(p, input, 0)
+}
+
+// allMatches over 'b', read the way the options say
+func (re *Regexp) allBytesMatches(b []byte, n int, deliver func(caps []int)) {
+	if re.latin1 {
+		allMatches(re.prog, latin1Bytes(b), n, deliver)
+		return
+	}
+	allMatches(re.prog, b, n, deliver)
+}
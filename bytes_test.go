@@ -0,0 +1,74 @@
+package goregex
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestLatin1Bytes(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   []byte
+		want    []byte // what Find returns with Latin1
+		utf8    []byte // and without it
+	}{
+		{`\xC0`, []byte{0xc0}, []byte{0xc0}, nil},
+		{`\xC0`, []byte("À"), nil, []byte("À")},
+		{`[\x80-\xff]+`, []byte{0x01, 0x80, 0xfe, 0xff, 0x02}, []byte{0x80, 0xfe, 0xff}, nil},
+		{`^..$`, []byte("é"), []byte("é"), nil},
+		{`.+`, []byte{0x00, 0xff, '\n', 0x80}, []byte{0x00, 0xff}, []byte{0x00, 0xff}},
+		{`(?i)\xe9+`, []byte{0xc9, 0xe9}, []byte{0xc9, 0xe9}, nil},
+		{`é`, []byte{0xe9}, []byte{0xe9}, nil},
+		{`\xff\b\w`, []byte{0xff, 'a'}, []byte{0xff, 'a'}, nil},
+		{`(.)\1`, []byte{'a', 0xfe, 0xfe}, []byte{0xfe, 0xfe}, []byte{0xfe, 0xfe}},
+		{`(?<=\xff\xfe)a`, []byte{0xff, 0xfe, 'a'}, []byte{'a'}, nil},
+		{`\xff(?=.*\x00)`, []byte{0xff, 0x80, 0x00}, []byte{0xff}, nil},
+		{`[^\x00-\x7f]{2}`, []byte{'a', 0xc3, 0xa9, 'b'}, []byte{0xc3, 0xa9}, nil},
+	}
+	for _, test := range tests {
+		for _, e := range engines {
+			latin1, err := CompileWithOptions(test.pattern, Latin1)
+			if err != nil {
+				t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+			}
+			plain, err := Compile(test.pattern)
+			if err != nil {
+				t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+			}
+			if latin1.SetEngine(e.engine) != nil || plain.SetEngine(e.engine) != nil {
+				continue
+			}
+			if got := latin1.Find(test.input); !bytes.Equal(got, test.want) || (got == nil) != (test.want == nil) {
+				t.Errorf("%s: Latin1 %q.Find(%x) = %x, want %x", e.name, test.pattern, test.input, got, test.want)
+			}
+			if got := latin1.Match(test.input); got != (test.want != nil) {
+				t.Errorf("%s: Latin1 %q.Match(%x) = %v, want %v", e.name, test.pattern, test.input, got, test.want != nil)
+			}
+			if got := plain.Find(test.input); !bytes.Equal(got, test.utf8) || (got == nil) != (test.utf8 == nil) {
+				t.Errorf("%s: %q.Find(%x) = %x, want %x", e.name, test.pattern, test.input, got, test.utf8)
+			}
+		}
+	}
+}
+
+func TestLatin1AllMatches(t *testing.T) {
+	re, err := CompileWithOptions(`[\x80-\xff]`, Latin1)
+	if err != nil {
+		t.Fatalf("Compile: %s", err.Render())
+	}
+	input := []byte{'a', 0xc3, 0xa9, 'b', 0xff}
+	if got := re.FindAll(input, -1); len(got) != 3 {
+		t.Errorf("FindAll(%x) = %x, want 3 single bytes", input, got)
+	}
+	if got, want := re.ReplaceAll(input, []byte("?")), []byte("a??b?"); !bytes.Equal(got, want) {
+		t.Errorf("ReplaceAll(%x) = %q, want %q", input, got, want)
+	}
+	if got, want := re.FindSubmatch(input), [][]byte{{0xc3}}; len(got) != 1 || !bytes.Equal(got[0], want[0]) {
+		t.Errorf("FindSubmatch(%x) = %x, want %x", input, got, want)
+	}
+	// the string functions still read UTF-8, where 0xff isn't valid and is read as U+FFFD
+	if got, want := re.FindAllIndex(string(input), -1), [][]int{{1, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindAllIndex(%q) = %v, want %v", input, got, want)
+	}
+}
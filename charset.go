@@ -0,0 +1,91 @@
+package goregex
+
+import (
+	"sort"
+	"unicode"
+)
+
+// a set of code points kept as sorted ranges that neither overlap nor touch,
+// every operation returns a new set in the same form
+type charset []runeRange
+
+// the set of the given ranges, in any order
+func newCharset(ranges ...runeRange) charset {
+	sorted := append([]runeRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].lo < sorted[j].lo
+	})
+	var set charset
+	for _, r := range sorted {
+		if last := len(set) - 1; last >= 0 && r.lo <= set[last].hi+1 {
+			set[last].hi = max(set[last].hi, r.hi)
+			continue
+		}
+		set = append(set, r)
+	}
+	return set
+}
+
+// whether 'ch' is in the set, by binary search since classes like \p{L} have hundreds of ranges
+func (c charset) contains(ch rune) bool {
+	i := sort.Search(len(c), func(i int) bool {
+		return c[i].hi >= ch
+	})
+	return i < len(c) && c[i].lo <= ch
+}
+
+func (c charset) union(other charset) charset {
+	return newCharset(append(append([]runeRange(nil), c...), other...)...)
+}
+
+func (c charset) intersect(other charset) charset {
+	var set charset
+	for i, j := 0, 0; i < len(c) && j < len(other); {
+		lo, hi := max(c[i].lo, other[j].lo), min(c[i].hi, other[j].hi)
+		if lo <= hi {
+			set = append(set, runeRange{lo: lo, hi: hi})
+		}
+		// move past the range that ends first, the other one may overlap the next
+		if c[i].hi < other[j].hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return set
+}
+
+func (c charset) subtract(other charset) charset {
+	return c.intersect(other.negate())
+}
+
+// every code point that isn't in the set
+func (c charset) negate() charset {
+	var set charset
+	next := rune(0)
+	for _, r := range c {
+		if r.lo > next {
+			set = append(set, runeRange{lo: next, hi: r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= unicode.MaxRune {
+		set = append(set, runeRange{lo: next, hi: unicode.MaxRune})
+	}
+	return set
+}
+
+// the set along with the other cases of every code point in it
+func (c charset) fold() charset {
+	folded := append([]runeRange(nil), c...)
+	for _, r := range c {
+		for ch := max(r.lo, minFold); ch <= min(r.hi, maxFold); ch++ {
+			for other := unicode.SimpleFold(ch); other != ch; other = unicode.SimpleFold(other) {
+				if other < r.lo || other > r.hi {
+					folded = append(folded, runeRange{lo: other, hi: other})
+				}
+			}
+		}
+	}
+	return newCharset(folded...)
+}
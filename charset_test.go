@@ -0,0 +1,43 @@
+package goregex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetOperations(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		want    []string
+	}{
+		{`[a-z&&[^aeiou]]+`, "hello world", []string{"h", "ll", "w", "rld"}},
+		{`[a-z--[aeiou]]+`, "hello world", []string{"h", "ll", "w", "rld"}},
+		{`[\w--\d]+`, "ab12cd_3", []string{"ab", "cd_"}},
+		{`[a-c--b]+`, "abcd", []string{"a", "c"}},
+		{`[a-z&&[^x]--[y]]+`, "wxyz", []string{"w", "z"}},
+		{`[\p{Greek}&&\p{Ll}]+`, "αΒγ", []string{"α", "γ"}},
+		{`[[:alpha:]&&[:upper:]]+`, "aBCd", []string{"BC"}},
+		// the whole result is negated
+		{`[^a-z&&[^aeiou]]+`, "hi! you", []string{"i! ", "ou"}},
+		// the case is folded in every operand
+		{`(?i)[a-z&&[^k]]+`, "kKaBK", []string{"aB"}},
+		// without anything after it '&&' stands for itself
+		{`[a&&]+`, "a&b", []string{"a&"}},
+		// elsewhere '[' is a literal
+		{`[a[b]+`, "[ab]", []string{"[ab"}},
+	}
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+		}
+		var got []string
+		for m := range re.All(test.input) {
+			got = append(got, m.Text())
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q matches in %q = %q, want %q", test.pattern, test.input, got, test.want)
+		}
+	}
+}
@@ -1,162 +1,501 @@
 package goregex
 
-func getChar(input string, pos int) uint8 {
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// inputText is what the matchers read, a string or a byte slice. both are read in place,
+// neither is ever converted into the other
+type inputText interface {
+	~string | ~[]byte
+}
+
+// get the code point starting at the byte offset 'pos' along with its width in bytes,
+// positions outside of the input are reported as the text boundaries with a width of 1
+func getChar[T inputText](input T, pos int) (rune, int) {
 	if pos >= 0 && pos < len(input) {
-		return input[pos]
+		if ch := input[pos]; ch < utf8.RuneSelf {
+			return rune(ch), 1
+		}
+		return decodeRune(input[pos:])
 	}
 
 	if pos >= len(input) {
+		return endOfText, 1
+	}
+
+	return startOfText, 1
+}
+
+// get the code point that ends right before the byte offset 'pos'
+func getPrevChar[T inputText](input T, pos int) rune {
+	if pos > 0 && pos <= len(input) {
+		if ch := input[pos-1]; ch < utf8.RuneSelf {
+			return rune(ch)
+		}
+		ch, _ := decodeLastRune(input[:pos])
+		return ch
+	}
+
+	if pos > len(input) {
 		return endOfText
 	}
 
 	return startOfText
 }
 
+// latin1Bytes is a byte slice read one byte per character, each byte standing for the code
+// point of the same value, see Latin1. only the decoding below tells it apart from []byte
+type latin1Bytes []byte
+
+// whether the input is read one byte per character instead of as UTF-8
+func isLatin1[T inputText]() bool {
+	var zero T
+	_, ok := any(zero).(latin1Bytes)
+	return ok
+}
+
+// utf8.DecodeRune for either kind of input, the few bytes of the code point are copied
+// to the stack so that a string doesn't have to become a byte slice
+func decodeRune[T inputText](input T) (rune, int) {
+	if isLatin1[T]() {
+		return rune(input[0]), 1
+	}
+	var buf [utf8.UTFMax]byte
+	n := copy(buf[:], input)
+	return utf8.DecodeRune(buf[:n])
+}
+
+// utf8.DecodeLastRune for either kind of input
+func decodeLastRune[T inputText](input T) (rune, int) {
+	if isLatin1[T]() {
+		return rune(input[len(input)-1]), 1
+	}
+	var buf [utf8.UTFMax]byte
+	n := copy(buf[:], input[max(len(input)-utf8.UTFMax, 0):])
+	return utf8.DecodeLastRune(buf[:n])
+}
+
+// whether 'input' starts with 'prefix'
+func hasPrefix[T inputText](input T, prefix T) bool {
+	return len(input) >= len(prefix) && string(input[:len(prefix)]) == string(prefix)
+}
+
 // get the next state given the 'ch' as an input
-func (s *State) nextStateWith(ch uint8) *State {
+func (s *State) nextStateWith(ch rune) *State {
 	states := s.transitions[ch]
 	if len(states) == 0 {
+		for _, class := range s.classes {
+			if class.matches(ch) {
+				return class.target
+			}
+		}
 		return nil
 	}
 	return states[0]
 }
 
-func (s *State) check(inputString string, pos int, started bool, ctx *regexCheckContext) bool {
-	if s.groups != nil {
-		// if this state has groups associated with it
-		// go through each group
-		for _, capturedGroup := range s.groups {
-			// if it's a start of a group
-			if capturedGroup.start {
-				c := &capture{
-					start: pos,
-					end:   -1,
-				}
-				// for each name of the captured group
-				// add the capture object
-				// a group can have 2 different names: numeric (\1) and user-set (\k<animal>)
-				for _, groupName := range capturedGroup.names {
-					ctx.groups[groupName] = c
-				}
-			}
-
-			// if the group ends
-			if capturedGroup.end {
-				// for each name of the captured group
-				// set the end to the current position
-				// a group can have 2 different names: numeric (\1) and user-set (\k<animal>)
-				for _, groupName := range capturedGroup.names {
-					if ctx.groups[groupName].end < pos {
-						// only update if the new position is greater
-						ctx.groups[groupName].end = pos
-					}
-				}
-			}
-		}
+// get the state reached by consuming 'ch', falling back to the wildcard
+func (s *State) step(ch rune) *State {
+	nextState := s.nextStateWith(ch)
+	// if there are no transitions for the current char as is
+	// then see if there's a transition for any char, i.e. dot (.) sign
+	if nextState == nil && ch >= 0 && ch != newline {
+		nextState = s.wildcard
 	}
+	return nextState
+}
 
-	currentChar := getChar(inputString, pos)
+// whether the anchors of this state hold at the byte offset 'pos'
+func assertionsHold[T inputText](s *State, input T, pos int) bool {
+	if s.assertions == 0 {
+		return true
+	}
+	if s.assertions&assertTextEndNewline != 0 &&
+		pos != len(input) && (pos != len(input)-1 || input[pos] != newline) {
+		return false
+	}
+	currentChar, _ := getChar(input, pos)
+	return s.assertionsHoldAround(getPrevChar(input, pos), currentChar)
+}
 
-	// the current character should be either EOF or
-	// the next one after that a newline to be valid, otherwise check fails
-	if s.endOfText && (currentChar != endOfText && currentChar != newline) {
+// whether the anchors of this state hold between 'previousChar' and 'currentChar',
+// \Z needs more than that and is left to assertionsHold
+func (s *State) assertionsHoldAround(previousChar rune, currentChar rune) bool {
+	if s.assertions&assertTextStart != 0 && previousChar != startOfText {
+		return false
+	}
+	if s.assertions&assertTextEnd != 0 && currentChar != endOfText {
 		return false
 	}
 
-	previousChar := getChar(inputString, pos-1)
-	// the current character should be either Start of File or
-	// the previous one before that a newline to be valid, otherwise check fails
-	if s.startOfText && (currentChar != startOfText && previousChar != newline) {
+	// the previous character should be either Start of File or
+	// a newline to be valid, otherwise check fails
+	if s.assertions&assertLineStart != 0 && previousChar != startOfText && previousChar != newline {
 		return false
 	}
 
-	if s.terminal {
-		return true
+	// the current character should be either EOF or
+	// a newline to be valid, otherwise check fails
+	if s.assertions&assertLineEnd != 0 && currentChar != endOfText && currentChar != newline {
+		return false
 	}
 
-	// if there's a backreference transition
-	if s.backreference != nil {
-		// get the captured reference
-		captured, found := ctx.groups[s.backreference.name]
-		if !found {
+	if s.assertions&(assertWordBoundary|assertNotWordBoundary) != 0 {
+		unicodeWord := s.assertions&assertUnicodeWord != 0
+		boundary := isWordChar(previousChar, unicodeWord) != isWordChar(currentChar, unicodeWord)
+		if s.assertions&assertWordBoundary != 0 && !boundary {
 			return false
 		}
-		// get the string value of it
-		capturedString := captured.string(inputString)
-		size := len(capturedString)
-		backreferenceCheckFailed := false
-		for i := 0; i < size; i++ {
-			// see if matches with the next set of characters
-			if pos >= len(inputString) || inputString[pos+i] != capturedString[i] {
-				backreferenceCheckFailed = true
-				break
-			}
+		if s.assertions&assertNotWordBoundary != 0 && boundary {
+			return false
 		}
-		if !backreferenceCheckFailed {
-			return s.backreference.target.check(inputString, pos+size, true, ctx)
+	}
+
+	return true
+}
+
+// whether the lookaround of this state holds at the byte offset 'pos' for the backtracking matcher.
+// with ctx.looks it's worked out without backtracking, otherwise its body is matched by the
+// backtracking matcher on a copy of the captures so that backreferences still see the groups
+func lookaroundHolds[T inputText](s *State, input T, pos int, parent *regexCheckContext) bool {
+	l := s.lookaround
+	if l == nil {
+		return true
+	}
+	if parent.looks != nil {
+		return lookaroundHoldsAt(parent.looks, l, input, pos)
+	}
+	ctx := &regexCheckContext{
+		caps:          append([]int(nil), parent.caps...),
+		onPath:        newOnPath(len(parent.onPath)),
+		lookaroundEnd: -1,
+	}
+	if !l.behind {
+		return check(l.body, input, pos, ctx) != l.negated
+	}
+
+	// go back one character at a time and see if the body gets from there to 'pos'
+	ctx.lookaroundEnd = pos
+	start := pos
+	for length := 0; length <= l.maxLen; length++ {
+		if length >= l.minLen && check(l.body, input, start, ctx) {
+			return !l.negated
 		}
-		// backreference check failed, let's see if 
-		// there are any other transitions we can use
+		if start == 0 {
+			break
+		}
+		_, size := decodeLastRune(input[:start])
+		start -= size
 	}
+	return l.negated
+}
 
-	nextState := s.nextStateWith(currentChar)
-	// if there are no transitions for the current char as is
-	// then see if there's a transition for any char, i.e. dot (.) sign
-	if nextState == nil && (currentChar != endOfText && currentChar != newline) {
-		nextState = s.nextStateWith(anyChar)
+// whether 'ch' is part of a word for \b and \B, the text boundaries aren't
+func isWordChar(ch rune, unicodeWord bool) bool {
+	if ch < utf8.RuneSelf {
+		return ch == '_' || isDig(ch) || isAlphaLow(ch) || isAlphaUp(ch)
 	}
+	return unicodeWord && (unicode.IsLetter(ch) || unicode.IsDigit(ch) || unicode.In(ch, unicode.Mn, unicode.Pc))
+}
 
-	result := nextState != nil && nextState.check(inputString, pos+1, true, ctx)
-	for _, state := range s.transitions[epsilonChar] {
-		// we need to evaluate all the epsilon transitions
-		// because there's a chance that we'll finish early
-		// while there's still more to process
-		result = state.check(inputString, pos, true, ctx) || result
-		result = (currentChar == startOfText && state.check(inputString, pos+1, true, ctx)) || result
+// backtracking matcher, tries the edges in their priority order (epsilons, atomic group,
+// backreference, then the character edge) and stops at the first path that reaches the terminal state.
+// the path is kept in a slice rather than followed by recursion, so that a long input can't
+// overflow the stack, only the bodies of the atomic groups and lookarounds get a check of their own
+func check[T inputText](start *State, input T, pos int, ctx *regexCheckContext) bool {
+	// the bodies checked meanwhile take another buffer, the frames of this one stay where they are
+	var path []checkFrame
+	if len(ctx.paths) > 0 {
+		path = ctx.paths[len(ctx.paths)-1]
+		ctx.paths = ctx.paths[:len(ctx.paths)-1]
 	}
+	defer func() {
+		ctx.paths = append(ctx.paths, path[:0])
+	}()
 
-	if result {
-		return true
+	// enter 's' at the byte offset 'at': write its captures and see that its anchors and
+	// lookaround hold, the state is only pushed when it has edges left to try
+	enter := func(s *State, at int) checkOutcome {
+		// an empty loop brought us back to the same state without consuming anything. the
+		// positions only go forward along the path, so it's the last time the state was entered
+		if i := ctx.onPath[s.id]; i >= 0 && i < len(path) && path[i].state == s && path[i].pos == at {
+			// whether the state that led here fails now depends on how that one does
+			top := &path[len(path)-1]
+			top.low = min(top.low, i)
+			return checkFailed
+		}
+		if ctx.failures.has(s, at) {
+			return checkFailed
+		}
+
+		undo := len(ctx.undo)
+		for _, capturedGroup := range s.groups {
+			startSlot, endSlot := 2*capturedGroup.index, 2*capturedGroup.index+1
+			if startSlot >= len(ctx.caps) {
+				// a group that isn't tracked
+				continue
+			}
+			// remember what the group overwrites, so that a failed path doesn't leave its captures behind
+			ctx.undo = append(ctx.undo, startSlot, ctx.caps[startSlot], endSlot, ctx.caps[endSlot])
+			// if it's a start of a group
+			if capturedGroup.start {
+				ctx.caps[startSlot] = at
+				ctx.caps[endSlot] = -1
+			}
+			// if the group ends
+			if capturedGroup.end {
+				ctx.caps[endSlot] = at
+			}
+		}
+
+		switch {
+		case !assertionsHold(s, input, at) || !lookaroundHolds(s, input, at, ctx):
+		case s.terminal:
+			return checkMatched
+		case s.atomicExit:
+			// the body of the atomic group matched, tell it where
+			ctx.atomicEnd = at
+			return checkMatched
+		case s.lookaroundExit:
+			if ctx.lookaroundEnd < 0 || at == ctx.lookaroundEnd {
+				return checkMatched
+			}
+		default:
+			path = append(path, checkFrame{
+				state:      s,
+				pos:        at,
+				undo:       undo,
+				bodyUndo:   -1,
+				prevOnPath: ctx.onPath[s.id],
+				low:        len(path),
+			})
+			ctx.onPath[s.id] = len(path) - 1
+			return checkEntered
+		}
+		ctx.undoTo(undo)
+		return checkFailed
 	}
 
-	// if we haven't started matching,
-	// then we need to move on to the next character
-	// while staying in the same state
-	if !started && pos+1 < len(inputString) {
-		return s.check(inputString, pos+1, false, ctx)
+	outcome := enter(start, pos)
+	for outcome != checkMatched && len(path) > 0 {
+		frame := &path[len(path)-1]
+		if next, at := nextEdge(frame, input, ctx); next != nil {
+			outcome = enter(next, at)
+			continue
+		}
+		// nothing left to try from this state
+		depth := len(path) - 1
+		if frame.low >= depth {
+			// it didn't fail because of a state further down the path, it always will
+			ctx.failures.add(frame.state, frame.pos)
+		} else {
+			path[depth-1].low = min(path[depth-1].low, frame.low)
+		}
+		ctx.undoTo(frame.undo)
+		ctx.onPath[frame.state.id] = frame.prevOnPath
+		path = path[:depth]
 	}
+	// the captures of a matching path stay
+	for i := len(path) - 1; i >= 0; i-- {
+		ctx.onPath[path[i].state.id] = path[i].prevOnPath
+	}
+	return outcome == checkMatched
+}
+
+// what came of entering a state
+type checkOutcome uint8
+
+const (
+	checkFailed  checkOutcome = iota
+	checkEntered              // its edges are to be tried
+	checkMatched              // it ends the path, the check succeeded
+)
 
-	return false
+// a state on the path of the backtracking matcher along with the next edge to try from it
+type checkFrame struct {
+	state *State
+	pos   int
+	edge  int // the epsilons come first, then the edges numbered below
+	undo  int // length of the undo log before the captures of the state
+	// length of the undo log before the body of the atomic group matched, -1 when it didn't
+	bodyUndo   int
+	prevOnPath int // the frame the state had further down the path, if any
+	// the lowest frame whose outcome this one's depends on, an empty loop back to a state on the
+	// path fails only while that state is being tried. the frame's own depth when there's none
+	low int
 }
 
-type Result struct {
-	Matches bool
-	Groups  map[string]string
+// the (state, byte offset) pairs the backtracking matcher failed from. without backreferences
+// nothing that came before a state changes what can follow it, so they're never tried again.
+// the bits are kept in pages made as they're needed, a match found early only pays for a few
+type failureMemo struct {
+	stateCount int
+	pageBits   int
+	pages      [][]uint64
+}
+
+// most bits in a page of the failure memo, a short input gets a single page of its size
+const failurePageBits = 1 << 15
+
+func newFailureMemo(stateCount int, inputLen int) *failureMemo {
+	size := stateCount * (inputLen + 1)
+	pageBits := min(failurePageBits, (size+63)/64*64)
+	return &failureMemo{
+		stateCount: stateCount,
+		pageBits:   pageBits,
+		pages:      make([][]uint64, (size+pageBits-1)/pageBits),
+	}
 }
 
-type capture struct {
-	start int
-	end   int
+func (m *failureMemo) has(s *State, pos int) bool {
+	if m == nil {
+		return false
+	}
+	i := pos*m.stateCount + s.id
+	page := m.pages[i/m.pageBits]
+	return page != nil && page[i%m.pageBits/64]&(1<<(i%64)) != 0
+}
+
+func (m *failureMemo) add(s *State, pos int) {
+	if m == nil {
+		return
+	}
+	i := pos*m.stateCount + s.id
+	page := m.pages[i/m.pageBits]
+	if page == nil {
+		page = make([]uint64, m.pageBits/64)
+		m.pages[i/m.pageBits] = page
+	}
+	page[i%m.pageBits/64] |= 1 << (i % 64)
 }
 
-func (c *capture) string(inputString string) string {
-	s := c.start
-	e := c.end
+// the edges tried after the epsilons, in their priority order
+const (
+	edgeAtomic      = iota
+	edgeAfterAtomic // what follows the atomic group failed, its body isn't tried another way
+	edgeBackreference
+	edgeChar
+	edgeCount
+)
+
+// the state to enter next from the frame and where, nil once every edge was tried
+func nextEdge[T inputText](frame *checkFrame, input T, ctx *regexCheckContext) (*State, int) {
+	s, pos := frame.state, frame.pos
+	for frame.edge < len(s.epsilon)+edgeCount {
+		edge := frame.edge
+		frame.edge++
+		if edge < len(s.epsilon) {
+			return s.epsilon[edge], pos
+		}
 
-	if s < 0 {
-		s = 0
+		switch edge - len(s.epsilon) {
+		case edgeAtomic:
+			if s.atomic == nil {
+				continue
+			}
+			undo := len(ctx.undo)
+			if check(s.atomic.body, input, pos, ctx) {
+				frame.bodyUndo = undo
+				return s.atomic.target, ctx.atomicEnd
+			}
+		case edgeAfterAtomic:
+			if frame.bodyUndo >= 0 {
+				// only the captures of the body are undone
+				ctx.undoTo(frame.bodyUndo)
+			}
+		case edgeBackreference:
+			if s.backreference == nil {
+				continue
+			}
+			// get the captured reference
+			start, end := ctx.caps[2*s.backreference.index], ctx.caps[2*s.backreference.index+1]
+			if start < 0 || end < start {
+				continue
+			}
+			// see if matches with the next set of characters
+			captured := input[start:end]
+			length := len(captured)
+			if s.backreference.foldCase {
+				length = foldedPrefix(input[pos:], captured)
+			} else if !hasPrefix(input[pos:], captured) {
+				length = -1
+			}
+			if length >= 0 {
+				return s.backreference.target, pos + length
+			}
+		case edgeChar:
+			currentChar, size := getChar(input, pos)
+			if nextState := s.step(currentChar); nextState != nil {
+				return nextState, pos + size
+			}
+		}
 	}
+	return nil, 0
+}
 
-	if e > len(inputString) || e == -1 {
-		e = len(inputString)
+// look for the leftmost match starting at or after the byte offset 'from'
+// by running the backtracking matcher at each position, returns the capture slots
+func backtrackMatch[T inputText](p *program, w *inputWindow[T], from int) []int {
+	input := w.text
+	// a failed check leaves the context as it found it, it's used for every position
+	checkContext := newCheckContext(p)
+	checkContext.looks = w.lookarounds(p)
+	checkContext.failures = w.backtrackFailures(p)
+	for pos := from; pos <= len(input); {
+		if check(p.start, input, pos, checkContext) {
+			return checkContext.caps
+		}
+		if pos == len(input) {
+			break
+		}
+		_, size := getChar(input, pos)
+		pos += size
 	}
+	return nil
+}
 
-	return inputString[s:e]
+type Result struct {
+	Matches bool
+	Groups  map[string]string
 }
 
 type regexCheckContext struct {
-	groups map[string]*capture
+	// start and end offsets of every group, group 'n' owns caps[2n] and caps[2n+1]
+	caps []int
+	// index of the last frame of each state on the path, -1 when it isn't on it. used to cut empty loops
+	onPath    []int
+	failures  *failureMemo // nil when what came before matters, i.e., with backreferences
+	atomicEnd int          // where the body of the last atomic group ended
+	// where the body of a lookbehind has to end, -1 when checking a lookahead or the pattern itself
+	lookaroundEnd int
+	looks         *lookaroundCache // nil when the lookarounds are matched by backtracking
+	// capture slots along with what they held before the states on the path wrote them
+	undo  []int
+	paths [][]checkFrame // buffers for the paths, kept from one check to the next
 }
 
+// put back what the captures held when the undo log was 'length' long
+func (ctx *regexCheckContext) undoTo(length int) {
+	for i := len(ctx.undo) - 2; i >= length; i -= 2 {
+		ctx.caps[ctx.undo[i]] = ctx.undo[i+1]
+	}
+	ctx.undo = ctx.undo[:length]
+}
 
+func newCheckContext(prog *program) *regexCheckContext {
+	return &regexCheckContext{
+		caps:          prog.newCaps(),
+		onPath:        newOnPath(len(prog.states)),
+		lookaroundEnd: -1,
+	}
+}
 
+func newOnPath(stateCount int) []int {
+	onPath := make([]int, stateCount)
+	for i := range onPath {
+		onPath[i] = -1
+	}
+	return onPath
+}
@@ -0,0 +1,232 @@
+package goregex
+
+import (
+	"encoding/binary"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// dfa states kept in the cache before it gets flushed
+	dfaMaxStates = 10000
+	// flushes tolerated during a single run before falling back to the NFA for good
+	dfaMaxFlushes = 8
+)
+
+// a state of the lazy dfa: the NFA states alive at some point of the input,
+// their epsilon edges are only followed once the next character is known
+// because the end anchors depend on it
+type dfaState struct {
+	states   []*State // sorted by id
+	prevChar rune     // representative of the previous character, for the anchors
+	ascii    [utf8.RuneSelf]*dfaState
+	other    map[rune]*dfaState
+	atEnd    *dfaState // outcome once the input is over, dfaMatched or dfaFailed
+}
+
+// outcomes of a transition besides moving to another state
+var (
+	dfaMatched = &dfaState{}
+	dfaFailed  = &dfaState{}
+)
+
+// subset construction of the NFA built one transition at a time while matching,
+// only answers whether there's a match so it's used for patterns without backreferences
+type lazyDFA struct {
+	mu      sync.Mutex
+	start   *State
+	cache   map[string]*dfaState
+	initial *dfaState
+	flushes int
+	// the cache thrashed during a run, the NFA is used from then on instead of trying again every time
+	gaveUp bool
+	// scratch space for the closures, same generation trick as the thread lists
+	mark []int
+	gen  int
+}
+
+func newLazyDFA(start *State, stateCount int) *lazyDFA {
+	return &lazyDFA{
+		start: start,
+		cache: map[string]*dfaState{},
+		mark:  make([]int, stateCount),
+	}
+}
+
+// the anchors only care whether the previous character was a text start, a newline or
+// a word character, keeping just that makes states that only differ in the previous character the same
+func dfaPrevChar(ch rune) rune {
+	switch {
+	case ch == startOfText || ch == newline:
+		return ch
+	case isWordChar(ch, false):
+		return 'a'
+	case isWordChar(ch, true):
+		// only a word character for the unicode aware \b
+		return 'é'
+	}
+	return 0
+}
+
+// report whether the pattern matches anywhere in the input,
+// 'ok' is false when the cache kept thrashing, now or in an earlier run, and the answer is unknown
+func dfaMatch[T inputText](d *lazyDFA, input T) (matched bool, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.gaveUp {
+		return false, false
+	}
+	d.flushes = 0
+	if d.initial == nil {
+		d.initial = d.intern([]*State{d.start}, startOfText, nil)
+	}
+	current := d.initial
+	for pos := 0; pos < len(input); {
+		var next *dfaState
+		ch, size := rune(input[pos]), 1
+		if ch < utf8.RuneSelf {
+			next = current.ascii[ch]
+		} else {
+			ch, size = decodeRune(input[pos:])
+			next = current.other[ch]
+		}
+		if next == nil {
+			if next = d.transition(current, ch); next == nil {
+				d.gaveUp = true
+				d.cache, d.initial = nil, nil
+				return false, false
+			}
+		}
+		if next == dfaMatched {
+			return true, true
+		}
+		current = next
+		pos += size
+	}
+
+	if current.atEnd == nil {
+		if _, found := d.closure(current, endOfText); found {
+			current.atEnd = dfaMatched
+		} else {
+			current.atEnd = dfaFailed
+		}
+	}
+	return current.atEnd == dfaMatched, true
+}
+
+// compute and cache the state reached from 'from' by consuming 'ch',
+// returns nil when the cache had to be flushed too many times
+func (d *lazyDFA) transition(from *dfaState, ch rune) *dfaState {
+	consuming, found := d.closure(from, ch)
+	next := dfaMatched
+	if !found {
+		var targets []*State
+		for _, s := range consuming {
+			if target := s.step(ch); target != nil {
+				targets = append(targets, target)
+			}
+		}
+		// the search isn't anchored, a new attempt starts at every position
+		targets = append(targets, d.start)
+		if next = d.intern(targets, dfaPrevChar(ch), from); next == nil {
+			return nil
+		}
+	}
+
+	if ch >= 0 && ch < utf8.RuneSelf {
+		from.ascii[ch] = next
+	} else {
+		if from.other == nil {
+			from.other = map[rune]*dfaState{}
+		}
+		from.other[ch] = next
+	}
+	return next
+}
+
+// follow the epsilon edges of the states in 'from' right before 'ch' is consumed,
+// returns the states waiting for a character and whether the terminal state was reached
+func (d *lazyDFA) closure(from *dfaState, ch rune) ([]*State, bool) {
+	d.gen++
+	var consuming []*State
+	found := false
+	stack := append([]*State{}, from.states...)
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if d.mark[s.id] == d.gen {
+			continue
+		}
+		d.mark[s.id] = d.gen
+		if !s.assertionsHoldAround(from.prevChar, ch) {
+			continue
+		}
+		if s.terminal {
+			found = true
+			continue
+		}
+		if len(s.transitions) > 0 || len(s.classes) > 0 || s.wildcard != nil {
+			consuming = append(consuming, s)
+		}
+		stack = append(stack, s.epsilon...)
+	}
+	return consuming, found
+}
+
+// find or create the dfa state for the given NFA states, flushing the cache when it's full.
+// 'keep' is the state being worked on, it survives the flush
+func (d *lazyDFA) intern(states []*State, prevChar rune, keep *dfaState) *dfaState {
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].id < states[j].id
+	})
+	unique := states[:0]
+	for i, s := range states {
+		if i == 0 || s != states[i-1] {
+			unique = append(unique, s)
+		}
+	}
+
+	key := dfaKey(unique, prevChar)
+	if cached, ok := d.cache[key]; ok {
+		return cached
+	}
+
+	if len(d.cache) >= dfaMaxStates {
+		d.flushes++
+		if d.flushes > dfaMaxFlushes {
+			return nil
+		}
+		d.flush(keep)
+	}
+	state := &dfaState{
+		states:   unique,
+		prevChar: prevChar,
+	}
+	d.cache[key] = state
+	return state
+}
+
+// drop every cached state but 'keep', whose transitions are forgotten
+func (d *lazyDFA) flush(keep *dfaState) {
+	d.cache = map[string]*dfaState{}
+	d.initial = nil
+	if keep == nil {
+		return
+	}
+	keep.ascii = [utf8.RuneSelf]*dfaState{}
+	keep.other = nil
+	keep.atEnd = nil
+	d.cache[dfaKey(keep.states, keep.prevChar)] = keep
+}
+
+// cache key of a dfa state, the ids of its sorted NFA states
+func dfaKey(states []*State, prevChar rune) string {
+	key := make([]byte, 0, 4*(len(states)+1))
+	key = binary.AppendVarint(key, int64(prevChar))
+	for _, s := range states {
+		key = binary.AppendUvarint(key, uint64(s.id))
+	}
+	return string(key)
+}
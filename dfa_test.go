@@ -0,0 +1,38 @@
+package goregex
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// the dfa for this pattern needs a state for each of the last 16 characters, on random input
+// its cache keeps filling up. it used to be built again and flushed on every call
+func TestDFAGivesUp(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var b strings.Builder
+	for range 120000 {
+		b.WriteByte("ab"[r.Intn(2)])
+	}
+	input := b.String()
+	re, err := Compile(`(a|b)*a(a|b){15}c`)
+	if err != nil {
+		t.Fatalf("Compile: %s", err.Render())
+	}
+	if re.MatchString(input) {
+		t.Errorf("MatchString(%d bytes) = true, want false", len(input))
+	}
+	if !re.prog.dfa.gaveUp {
+		t.Fatalf("the dfa didn't give up on %d bytes", len(input))
+	}
+	if _, ok := dfaMatch(re.prog.dfa, "ab"); ok {
+		t.Errorf("the dfa was tried again after giving up")
+	}
+	matching := input + "a" + strings.Repeat("b", 15) + "c"
+	if !re.MatchString(matching) {
+		t.Errorf("MatchString(%d bytes) = false, want true", len(matching))
+	}
+	if got := re.FindIndex(matching); got == nil || got[1] != len(matching) {
+		t.Errorf("FindIndex(%d bytes) = %v, want a match up to the end", len(matching), got)
+	}
+}
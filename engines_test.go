@@ -0,0 +1,133 @@
+package goregex
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// patterns whose meaning is the same in RE2, so that every engine can be held to the regexp package
+var enginePatterns = []string{
+	`a`,
+	`abc`,
+	`a*`,
+	`a+b`,
+	`a?b?c`,
+	`a*?`,
+	`a+?b`,
+	`(a|ab)(c|bcd)(d*)`,
+	`(a+)(b+)?`,
+	`(a*)*`,
+	`(a*)+b`,
+	`(a|b)*c`,
+	`(?:ab)*c`,
+	`x*(a|b*)*y?`,
+	`a{2}`,
+	`a{2,}`,
+	`a{1,3}?`,
+	`(ab){0}c`,
+	`[a-c]+`,
+	`[^a-c]+`,
+	`[\x00-\x1f]+`,
+	`[]a]+`,
+	`[^]a]`,
+	`\d+`,
+	`\w+\s`,
+	`\bfoo\b`,
+	`\Bo`,
+	`^a`,
+	`a$`,
+	`(?m)^\w+$`,
+	`(?s)a.b`,
+	`a.b`,
+	`(?i)straße`,
+	`(?i)[k-m]+`,
+	`(?U)a+`,
+	`(?<word>\w+)@(?<host>\w+)`,
+	`\pL+`,
+	`\p{Greek}+`,
+	`[[:alpha:]]+`,
+	`\x{263a}`,
+	`日本+`,
+	`.`,
+	``,
+	`|a`,
+	`\A\w`,
+	`\w\z`,
+}
+
+var engineInputs = []string{
+	"",
+	"a",
+	"abcbcd",
+	"aaabbbc",
+	"xaby ab abc",
+	"foo food fooo",
+	"one\ntwo\nthree",
+	"a\nb a-b",
+	"STRASSE straße Kelvin K",
+	"user@host, other@place",
+	"αβγ abc ☺ 日本本本",
+	"]a]b\x01\x02 ",
+}
+
+var engines = []struct {
+	name   string
+	engine Engine
+}{
+	{"auto", EngineAuto},
+	{"backtrack", EngineBacktrack},
+	{"pikevm", EnginePikeVM},
+}
+
+func TestEnginesAgainstRegexp(t *testing.T) {
+	for _, pattern := range enginePatterns {
+		want := regexp.MustCompile(pattern)
+		for _, e := range engines {
+			re, err := Compile(pattern)
+			if err != nil {
+				t.Fatalf("Compile(%q): %s", pattern, err.Render())
+			}
+			if err := re.SetEngine(e.engine); err != nil {
+				continue
+			}
+			for _, input := range engineInputs {
+				if got, want := re.FindAllSubmatchIndex(input, -1), want.FindAllStringSubmatchIndex(input, -1); !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: %q.FindAllSubmatchIndex(%q) = %v, want %v", e.name, pattern, input, got, want)
+				}
+				if got, want := re.FindSubmatchIndex(input), want.FindStringSubmatchIndex(input); !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: %q.FindSubmatchIndex(%q) = %v, want %v", e.name, pattern, input, got, want)
+				}
+				if got, want := re.MatchString(input), want.MatchString(input); got != want {
+					t.Errorf("%s: %q.MatchString(%q) = %v, want %v", e.name, pattern, input, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestScannerAgainstRegexp(t *testing.T) {
+	for _, pattern := range enginePatterns {
+		want := regexp.MustCompile(pattern)
+		re, err := Compile(pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %s", pattern, err.Render())
+		}
+		for _, input := range engineInputs {
+			// one byte per read, so that matches and characters get split between reads
+			scanner := re.NewScanner(iotest.OneByteReader(strings.NewReader(input)))
+			var got [][]int
+			for scanner.Next() {
+				got = append(got, scanner.Index())
+			}
+			if err := scanner.Err(); err != nil {
+				t.Errorf("%q.NewScanner(%q): %v", pattern, input, err)
+			}
+			if want := want.FindAllStringSubmatchIndex(input, -1); !reflect.DeepEqual(got, want) {
+				t.Errorf("%q.NewScanner(%q) matches = %v, want %v", pattern, input, got, want)
+			}
+		}
+	}
+}
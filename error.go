@@ -1,6 +1,10 @@
 package goregex
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
 
 type ParseErrorCode string
 
@@ -8,13 +12,79 @@ const (
 	SyntaxError      ParseErrorCode = "SyntaxError"
 	CompilationError ParseErrorCode = "CompilationError"
 )
-	
+
+// ErrorKind tells what's wrong with a pattern, every RegexError wraps one
+// so they can be told apart with errors.Is, e.g., errors.Is(err, ErrBadRange)
+type ErrorKind string
+
+func (k ErrorKind) Error() string {
+	return string(k)
+}
+
+const (
+	ErrUnbalancedParen       ErrorKind = "unbalanced parenthesis"
+	ErrUnbalancedBracket     ErrorKind = "missing closing ]"
+	ErrBadRange              ErrorKind = "invalid character class range"
+	ErrBadClass              ErrorKind = "invalid character class"
+	ErrBadRepetition         ErrorKind = "invalid repetition"
+	ErrMissingRepeatArgument ErrorKind = "missing argument to repetition operator"
+	ErrRepetitionTooLarge    ErrorKind = "repetition count too large"
+	ErrTrailingBackslash     ErrorKind = "trailing backslash at end of expression"
+	ErrUnknownEscape         ErrorKind = "unknown escape sequence"
+	ErrBadEscape             ErrorKind = "invalid escape sequence"
+	ErrBadGroupName          ErrorKind = "invalid group name"
+	ErrBadFlags              ErrorKind = "invalid or unsupported flags"
+	ErrBadBackreference      ErrorKind = "invalid backreference"
+	ErrUnknownGroup          ErrorKind = "unknown group"
+	ErrUnboundedLookbehind   ErrorKind = "lookbehind without a bounded length"
+	ErrUnsupported           ErrorKind = "unsupported by the engine"
+	ErrInternal              ErrorKind = "internal error"
+)
+
 type RegexError struct {
 	Code    ParseErrorCode
+	Kind    ErrorKind
 	Message string
-	Pos     int
+	Pos     int    // byte offset in the pattern where the offending text starts
+	End     int    // byte offset right after the offending text, never less than Pos
+	Pattern string // the pattern that failed, set by Compile
 }
 
 func (p *RegexError) Error() string {
 	return fmt.Sprintf("code=%s, message=%s, pos=%d", p.Code, p.Message, p.Pos)
 }
+
+// Unwrap exposes the kind of the error to errors.Is and errors.As
+func (p *RegexError) Unwrap() error {
+	if p == nil || p.Kind == "" {
+		return nil
+	}
+	return p.Kind
+}
+
+// Render returns the message followed by the pattern with the offending text underlined, e.g.,
+//
+//	invalid character class range: 'z-a' Range Not Acceptable
+//	[z-a]
+//	 ^^^
+func (p *RegexError) Render() string {
+	var b strings.Builder
+	if p.Kind != "" {
+		b.WriteString(string(p.Kind))
+		b.WriteString(": ")
+	}
+	b.WriteString(p.Message)
+	if p.Pattern == "" {
+		return b.String()
+	}
+
+	start := min(max(p.Pos, 0), len(p.Pattern))
+	end := min(max(p.End, start), len(p.Pattern))
+	b.WriteByte('\n')
+	b.WriteString(p.Pattern)
+	b.WriteByte('\n')
+	// columns are counted in code points, so that the carets line up under multibyte characters
+	b.WriteString(strings.Repeat(" ", utf8.RuneCountInString(p.Pattern[:start])))
+	b.WriteString(strings.Repeat("^", max(utf8.RuneCountInString(p.Pattern[start:end]), 1)))
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package goregex
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		pattern string
+		kind    ErrorKind
+		pos     int
+		end     int
+	}{
+		{`(a`, ErrUnbalancedParen, 0, 1},
+		{`a)`, ErrUnbalancedParen, 1, 2},
+		{`(?>a`, ErrUnbalancedParen, 0, 1},
+		{`[a`, ErrUnbalancedBracket, 0, 2},
+		{`[z-a]`, ErrBadRange, 1, 4},
+		{`[[:foo:]]`, ErrBadClass, 1, 8},
+		{`\p{Foo}`, ErrBadClass, 0, 7},
+		{`a**`, ErrBadRepetition, 2, 3},
+		{`x{2}{3}`, ErrBadRepetition, 4, 7},
+		{`a{2,1}`, ErrBadRepetition, 1, 6},
+		{`*a`, ErrMissingRepeatArgument, 0, 1},
+		{`a|*`, ErrMissingRepeatArgument, 2, 3},
+		{`a{1001}`, ErrRepetitionTooLarge, 1, 7},
+		{`a\`, ErrTrailingBackslash, 1, 2},
+		{`\q`, ErrUnknownEscape, 0, 2},
+		{`\x{zz}`, ErrBadEscape, 0, 5},
+		{`(?<>x)`, ErrBadGroupName, 0, 4},
+		{`(?<x`, ErrBadGroupName, 0, 4},
+		{`(?z)`, ErrBadFlags, 0, 3},
+		{`\2(a)`, ErrUnknownGroup, 0, 2},
+		{`\k<nope>(a)`, ErrUnknownGroup, 0, 8},
+		{`(?<=a+)b`, ErrUnboundedLookbehind, 0, 7},
+	}
+	for _, test := range tests {
+		_, err := Compile(test.pattern)
+		if err == nil {
+			t.Errorf("Compile(%q) succeeded, want %q", test.pattern, test.kind)
+			continue
+		}
+		if !errors.Is(err, test.kind) {
+			t.Errorf("Compile(%q) = %q, want errors.Is %q", test.pattern, err.Kind, test.kind)
+		}
+		var kind ErrorKind
+		if !errors.As(err, &kind) || kind != test.kind {
+			t.Errorf("Compile(%q): errors.As gives %q, want %q", test.pattern, kind, test.kind)
+		}
+		if err.Pos != test.pos || err.End != test.end {
+			t.Errorf("Compile(%q) points at [%d, %d), want [%d, %d)", test.pattern, err.Pos, err.End, test.pos, test.end)
+		}
+		if err.Pattern != test.pattern {
+			t.Errorf("Compile(%q) error has the pattern %q", test.pattern, err.Pattern)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`[z-a]`, "invalid character class range: 'z-a' Range Not Acceptable\n[z-a]\n ^^^"},
+		{`a)`, "unbalanced parenthesis: Unmatched ')'\na)\n ^"},
+		{`a{1001}`, "repetition count too large: '{1001}' repeats more than 1000 times\na{1001}\n ^^^^^^"},
+		// the carets are counted in characters, not bytes
+		{`日本[z-a]`, "invalid character class range: 'z-a' Range Not Acceptable\n日本[z-a]\n   ^^^"},
+	}
+	for _, test := range tests {
+		_, err := Compile(test.pattern)
+		if err == nil {
+			t.Errorf("Compile(%q) succeeded", test.pattern)
+			continue
+		}
+		if got := err.Render(); got != test.want {
+			t.Errorf("Compile(%q).Render() =\n%s\nwant\n%s", test.pattern, got, test.want)
+		}
+	}
+
+	// without the pattern there's only the message
+	err := &RegexError{Kind: ErrBadRange, Message: "'z-a' Range Not Acceptable", Pos: 1, End: 4}
+	if got, want := err.Render(), "invalid character class range: 'z-a' Range Not Acceptable"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	// an empty span still gets a caret, and positions past the pattern are kept in it
+	err = &RegexError{Kind: ErrTrailingBackslash, Message: "m", Pos: 9, End: 3, Pattern: "ab"}
+	if got, want := err.Render(), "trailing backslash at end of expression: m\nab\n  ^"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
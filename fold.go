@@ -0,0 +1,53 @@
+package goregex
+
+import "unicode"
+
+// code points outside of these bounds have no other case
+const (
+	minFold = 'A'
+	maxFold = 0x1e943
+)
+
+// every code point that's the same as 'ch' when the case is ignored, 'ch' included
+func foldOrbit(ch rune) []rune {
+	orbit := []rune{ch}
+	for other := unicode.SimpleFold(ch); other != ch; other = unicode.SimpleFold(other) {
+		orbit = append(orbit, other)
+	}
+	return orbit
+}
+
+// whether 'a' and 'b' are the same code point when the case is ignored
+func equalFold(a rune, b rune) bool {
+	if a == b {
+		return true
+	}
+	if a < 0 || b < 0 {
+		return false
+	}
+	for other := unicode.SimpleFold(a); other != a; other = unicode.SimpleFold(other) {
+		if other == b {
+			return true
+		}
+	}
+	return false
+}
+
+// length in bytes of the start of 'input' that's the same as 'prefix' when the case is ignored,
+// -1 when it isn't there. the lengths can differ, e.g., 'ſ' takes two bytes and 's' only one
+func foldedPrefix[T inputText](input T, prefix T) int {
+	n := 0
+	for i := 0; i < len(prefix); {
+		want, wantSize := decodeRune(prefix[i:])
+		if n >= len(input) {
+			return -1
+		}
+		got, size := decodeRune(input[n:])
+		if !equalFold(got, want) {
+			return -1
+		}
+		n += size
+		i += wantSize
+	}
+	return n
+}
@@ -0,0 +1,92 @@
+package goregex
+
+import "iter"
+
+// Match is a match handed out by All and AllSubmatches. the text of the groups is taken
+// from the input when asked for, nothing is built for the groups that aren't looked at
+type Match struct {
+	prog  *program
+	input string
+	caps  []int
+}
+
+// Start returns the byte offset of the match in the input
+func (m Match) Start() int {
+	return m.caps[0]
+}
+
+// End returns the byte offset right after the match
+func (m Match) End() int {
+	return m.caps[1]
+}
+
+// Text returns the text of the match
+func (m Match) Text() string {
+	return m.input[m.caps[0]:m.caps[1]]
+}
+
+// GroupSpan returns the start and end byte offsets of the group numbered 'index',
+// both -1 when it didn't take part in the match or isn't tracked. group 0 is the whole match
+func (m Match) GroupSpan(index int) (int, int) {
+	if index < 0 || 2*index+1 >= len(m.caps) {
+		return -1, -1
+	}
+	start, end := m.caps[2*index], m.caps[2*index+1]
+	if start < 0 || end < start {
+		return -1, -1
+	}
+	return start, end
+}
+
+// Group returns the text of the group numbered 'index', "" when it didn't take part in the match
+func (m Match) Group(index int) string {
+	start, end := m.GroupSpan(index)
+	if start < 0 {
+		return ""
+	}
+	return m.input[start:end]
+}
+
+// NamedGroup returns the text of the group with the given name, "" when there's no such group
+// or it didn't take part in the match
+func (m Match) NamedGroup(name string) string {
+	index, found := m.prog.groupIndex[name]
+	if !found {
+		return ""
+	}
+	return m.Group(index)
+}
+
+// All yields the successive non overlapping matches in 'inputString', the same ones as
+// FindAllIndex, finding each one only when the previous one has been handled so that the
+// loop can stop early. only the bounds of the matches are tracked, the groups of a Match
+// are only there with AllSubmatches
+func (re *Regexp) All(inputString string) iter.Seq[Match] {
+	prog := re.prog
+	if !prog.needsBacktrack {
+		// backreferences need their groups, other patterns can be matched without any
+		spanOnly := *prog
+		spanOnly.spanOnly = true
+		prog = &spanOnly
+	}
+	return func(yield func(Match) bool) {
+		cursor := newMatchCursor(prog, inputString)
+		for caps := cursor.next(); caps != nil; caps = cursor.next() {
+			if !yield(Match{prog: prog, input: inputString, caps: caps[0:2]}) {
+				return
+			}
+		}
+	}
+}
+
+// AllSubmatches is All with the groups of each Match
+func (re *Regexp) AllSubmatches(inputString string) iter.Seq[Match] {
+	return func(yield func(Match) bool) {
+		cursor := newMatchCursor(re.prog, inputString)
+		for caps := cursor.next(); caps != nil; caps = cursor.next() {
+			if !yield(Match{prog: re.prog, input: inputString, caps: caps}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package goregex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+	}{
+		{`\w+`, "one two  three"},
+		{`a*`, "baaac"},
+		{`(a)(b)?`, "ab a"},
+		{`(\w)\1`, "aabcc"},
+		{`x`, "abc"},
+	}
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+		}
+		var spans, groups [][]int
+		for m := range re.All(test.input) {
+			spans = append(spans, []int{m.Start(), m.End()})
+		}
+		for m := range re.AllSubmatches(test.input) {
+			var caps []int
+			for i := 0; i <= re.NumSubexp(); i++ {
+				start, end := m.GroupSpan(i)
+				caps = append(caps, start, end)
+			}
+			groups = append(groups, caps)
+		}
+		if want := re.FindAllIndex(test.input, -1); !reflect.DeepEqual(spans, want) {
+			t.Errorf("%q.All(%q) = %v, want %v", test.pattern, test.input, spans, want)
+		}
+		if want := re.FindAllSubmatchIndex(test.input, -1); !reflect.DeepEqual(groups, want) {
+			t.Errorf("%q.AllSubmatches(%q) = %v, want %v", test.pattern, test.input, groups, want)
+		}
+	}
+}
+
+func TestAllStopsEarly(t *testing.T) {
+	re, err := Compile(`\d`)
+	if err != nil {
+		t.Fatalf("Compile: %s", err.Render())
+	}
+	// the range statement panics if the sequence goes on after the loop was left
+	var texts []string
+	for m := range re.All("1a2b3c4") {
+		texts = append(texts, m.Text())
+		if len(texts) == 2 {
+			break
+		}
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("All = %q, want %q", texts, want)
+	}
+	count := 0
+	for range re.AllSubmatches("1a2b3c4") {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("AllSubmatches went through %d matches, want 3", count)
+	}
+}
+
+func TestMatchGroups(t *testing.T) {
+	re, err := Compile(`(?<key>\w+)=(?<value>\d+)?(x)?`)
+	if err != nil {
+		t.Fatalf("Compile: %s", err.Render())
+	}
+	var got [][]string
+	for m := range re.AllSubmatches("a=1 b= c=2x") {
+		got = append(got, []string{m.Text(), m.NamedGroup("key"), m.NamedGroup("value"), m.Group(3), m.NamedGroup("nope"), m.Group(9)})
+	}
+	want := [][]string{
+		{"a=1", "a", "1", "", "", ""},
+		{"b=", "b", "", "", "", ""},
+		{"c=2x", "c", "2", "x", "", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %q, want %q", got, want)
+	}
+
+	// All only tracks the bounds of the matches
+	for m := range re.All("a=1") {
+		if start, end := m.GroupSpan(0); start != 0 || end != 3 {
+			t.Errorf("All: GroupSpan(0) = %d, %d, want 0, 3", start, end)
+		}
+		if key := m.NamedGroup("key"); key != "" {
+			t.Errorf("All: NamedGroup(\"key\") = %q, want nothing", key)
+		}
+	}
+}
@@ -1,72 +1,325 @@
 package goregex
 
-func Compile(regexString string) (*State, *RegexError) {
+// Engine selects how a compiled pattern is executed
+type Engine uint8
+
+const (
+	// EngineAuto uses the pike vm unless the pattern needs backtracking, i.e., it has backreferences,
+	// atomic groups or possessive quantifiers
+	EngineAuto Engine = iota
+	// EngineBacktrack explores one path at a time and supports everything. a state that failed at a position
+	// isn't tried there again, except with backreferences which make it take exponential time at worst
+	EngineBacktrack
+	// EnginePikeVM simulates every path at once in O(n*m) time, patterns that need backtracking are rejected.
+	// a lookaround with a bounded length goes through its characters again at each position it's checked at,
+	// one without a bounded length is worked out for the whole input in a single pass
+	EnginePikeVM
+)
+
+// Options change how a pattern is read, they're combined with '|'
+type Options uint16
+
+const (
+	// CaseInsensitive ignores the case of letters, `(?i)` inline
+	CaseInsensitive Options = 1 << iota
+	// Multiline makes `^` and `$` match at the start and end of every line instead of the whole text only, `(?m)` inline
+	Multiline
+	// DotAll lets `.` match a newline too, `(?s)` inline
+	DotAll
+	// Ungreedy makes the quantifiers lazy, they match as few times as possible first, `(?U)` inline
+	Ungreedy
+	// UnicodeWord makes `\b` and `\B` take any unicode letter or digit as a word character, not only `[0-9A-Za-z_]`
+	UnicodeWord
+	// Latin1 makes the byte slice functions read one byte per character, the byte standing for the code point
+	// of the same value as in ISO 8859-1, so that `\xC0` or `[\x80-\xff]` match raw bytes of binary data.
+	// the string and reader functions still read UTF-8
+	Latin1
+)
+
+// Regexp is a compiled pattern, along with what's known about it
+type Regexp struct {
+	expr        string
+	prog        *program
+	subexpNames []string
+	latin1      bool // the byte slice functions read one byte per character
+}
+
+// Compile parses the pattern and builds its matcher, a failure comes with the
+// pattern attached so that RegexError.Render can point at the offending text
+func Compile(regexString string) (*Regexp, *RegexError) {
+	return CompileWithOptions(regexString, 0)
+}
+
+// CompileWithOptions is Compile with the given options applied to the whole pattern,
+// the same as starting it with the inline flags, e.g., `(?is)`
+func CompileWithOptions(regexString string, options Options) (*Regexp, *RegexError) {
 	parseContext := parsingContext{
-		pos:            0,
-		tokens:         []rgToken{},
-		capturedGroups: map[string]bool{},
+		pos:        0,
+		tokens:     []rgToken{},
+		groupNames: &[]string{""},
+		flags:      options,
 	}
 	if err := parse(regexString, &parseContext); err != nil {
+		err.Pattern = regexString
+		return nil, err
+	}
+	prog, err := toNfa(&parseContext)
+	if err != nil {
+		err.Pattern = regexString
 		return nil, err
 	}
-	return toNfa(&parseContext)
+	return &Regexp{
+		expr:        regexString,
+		prog:        prog,
+		subexpNames: *parseContext.groupNames,
+		latin1:      options&Latin1 != 0,
+	}, nil
+}
+
+// String returns the source text of the pattern
+func (re *Regexp) String() string {
+	return re.expr
+}
+
+// NumSubexp returns the number of capturing groups in the pattern
+func (re *Regexp) NumSubexp() int {
+	return len(re.subexpNames) - 1
+}
+
+// SubexpNames returns the names of the capturing groups, indexed by the group number.
+// names[0] stands for the whole match and unnamed groups have an empty name
+func (re *Regexp) SubexpNames() []string {
+	return append([]string(nil), re.subexpNames...)
+}
+
+// SubexpIndex returns the number of the group with the given name, -1 when there's none
+func (re *Regexp) SubexpIndex(name string) int {
+	if name == "" {
+		return -1
+	}
+	for index, groupName := range re.subexpNames {
+		if groupName == name {
+			return index
+		}
+	}
+	return -1
+}
+
+// SetEngine picks the matching engine used by Test and FindMatches
+func (re *Regexp) SetEngine(engine Engine) *RegexError {
+	if engine == EnginePikeVM && re.prog.needsBacktrack {
+		return &RegexError{
+			Code:    CompilationError,
+			Kind:    ErrUnsupported,
+			Message: "backreferences, atomic groups and possessive quantifiers are only supported by the backtracking engine",
+		}
+	}
+	re.prog.engine = engine
+	return nil
+}
+
+// run the selected engine from the byte offset 'from', returns the capture slots of the match
+func match[T inputText](p *program, input T, from int) []int {
+	return matchWhole(p, newWindow(input), from)
 }
-func (s *State) Test(inputString string) Result {
-	checkContext := &regexCheckContext{
-		groups: map[string]*capture{},
+
+// match over a window that holds the whole input, what's worked out about the
+// input along the way is kept in the window for the next searches
+func matchWhole[T inputText](p *program, w *inputWindow[T], from int) []int {
+	switch p.engine {
+	case EngineBacktrack:
+		return backtrackMatch(p, w, from)
+	case EnginePikeVM:
+		return pikeRun(p, w, from)
+	}
+	if p.needsBacktrack {
+		return backtrackMatch(p, w, from)
 	}
+	return pikeRun(p, w, from)
+}
 
-	result := s.check(inputString, -1, s.startOfText, checkContext)
+// whether the lazy dfa can tell that there's no match, without tracking any group
+func ruledOut[T inputText](p *program, input T) bool {
+	if p.dfa == nil || p.engine != EngineAuto {
+		return false
+	}
+	matched, ok := dfaMatch(p.dfa, input)
+	return ok && !matched
+}
 
-	// prepare the result
-	groups := map[string]string{}
+// MatchString reports whether the pattern matches anywhere in the input,
+// it's answered by the lazy dfa whenever the pattern allows it, until an input needs more dfa
+// states than it can keep, after which the pattern is matched by its engine
+func (re *Regexp) MatchString(inputString string) bool {
+	return matches(re.prog, inputString)
+}
 
-	if result {
-		// extract strings from the groups
-		for groupName, captured := range checkContext.groups {
-			groups[groupName] = captured.string(inputString)
+// whether there's a match, from the lazy dfa when it can tell
+func matches[T inputText](p *program, input T) bool {
+	if p.dfa != nil && p.engine == EngineAuto {
+		if matched, ok := dfaMatch(p.dfa, input); ok {
+			return matched
 		}
 	}
+	return match(p, input, 0) != nil
+}
 
-	return Result{
-		Matches: result,
-		Groups:  groups,
+func (re *Regexp) Test(inputString string) Result {
+	if ruledOut(re.prog, inputString) {
+		return re.prog.result(inputString, nil)
 	}
+	return re.prog.result(inputString, match(re.prog, inputString, 0))
 }
 
-func (s *State) FindMatches(inputString string) []Result {
+// FindMatches returns the successive non overlapping matches along with their groups,
+// at most 'n' of them unless 'n' is negative, the same ones as FindAllSubmatchIndex
+func (re *Regexp) FindMatches(inputString string, n int) []Result {
 	var results []Result
-	start := -1
-	for start < len(inputString) {
-		checkContext := &regexCheckContext{
-			groups: map[string]*capture{},
-		}
-		result := s.check(inputString, start, s.startOfText, checkContext)
-		if !result {
-			break
+	allMatches(re.prog, inputString, n, func(caps []int) {
+		results = append(results, re.prog.result(inputString, caps))
+	})
+	return results
+}
+
+// walks through the leftmost-first, non overlapping matches from left to right:
+// each match is the leftmost one that starts at or after the end of the previous one,
+// an empty match right after the previous match is skipped,
+// and the search moves one character past an empty match
+type matchCursor[T inputText] struct {
+	prog         *program
+	window       *inputWindow[T]
+	pos          int
+	prevMatchEnd int
+}
+
+func newMatchCursor[T inputText](p *program, input T) *matchCursor[T] {
+	return newWindowCursor(p, newWindow(input))
+}
+
+func newWindowCursor[T inputText](p *program, w *inputWindow[T]) *matchCursor[T] {
+	return &matchCursor[T]{
+		prog:         p,
+		window:       w,
+		prevMatchEnd: -1,
+	}
+}
+
+// the capture slots of the next match, nil once there are no more
+func (c *matchCursor[T]) next() []int {
+	// the end of the input is only known once the window got there
+	for !c.window.eof || c.pos <= c.window.base+len(c.window.text) {
+		caps := matchWindow(c.prog, c.window, c.pos)
+		if caps == nil {
+			c.pos = c.window.base + len(c.window.text) + 1
+			return nil
 		}
-		// prepare the result
-		groups := map[string]string{}
-
-		if result {
-			// extract strings from the groups
-			for groupName, captured := range checkContext.groups {
-				groups[groupName] = captured.string(inputString)
-				if groupName == "0" {
-					start = captured.end + 1
-				}
+
+		accept := true
+		if caps[1] == c.pos {
+			// an empty match, it's not reported when it touches the previous match
+			if caps[0] == c.prevMatchEnd {
+				accept = false
 			}
+			_, size := getChar(c.window.text, c.pos-c.window.base)
+			c.pos += size
+		} else {
+			c.pos = caps[1]
 		}
+		c.prevMatchEnd = caps[1]
 
-		r := Result{
-			Matches: result,
-			Groups:  groups,
+		if accept {
+			return caps
 		}
+	}
+	return nil
+}
+
+// hand the successive matches to 'deliver', at most 'n' of them unless 'n' is negative
+func allMatches[T inputText](p *program, input T, n int, deliver func(caps []int)) {
+	cursor := newMatchCursor(p, input)
+	for count := 0; n < 0 || count < n; count++ {
+		caps := cursor.next()
+		if caps == nil {
+			return
+		}
+		deliver(caps)
+	}
+}
+
+// FindIndex returns the start and end byte offsets of the leftmost match, nil when there's none
+func (re *Regexp) FindIndex(inputString string) []int {
+	if ruledOut(re.prog, inputString) {
+		return nil
+	}
+	caps := match(re.prog, inputString, 0)
+	if caps == nil {
+		return nil
+	}
+	return caps[0:2]
+}
+
+// FindSubmatchIndex returns the byte offsets of the leftmost match and its groups,
+// group 'n' spans result[2n] to result[2n+1], both -1 when it didn't take part in the match
+func (re *Regexp) FindSubmatchIndex(inputString string) []int {
+	if ruledOut(re.prog, inputString) {
+		return nil
+	}
+	return match(re.prog, inputString, 0)
+}
+
+// FindAllIndex returns the start and end byte offsets of the successive non overlapping matches,
+// at most 'n' of them unless 'n' is negative. nil when there's no match
+func (re *Regexp) FindAllIndex(inputString string, n int) [][]int {
+	var results [][]int
+	allMatches(re.prog, inputString, n, func(caps []int) {
+		results = append(results, caps[0:2])
+	})
+	return results
+}
 
-		results = append(results, r)
+// FindAllSubmatchIndex is FindAllIndex with the offsets of the groups, laid out as in FindSubmatchIndex
+func (re *Regexp) FindAllSubmatchIndex(inputString string, n int) [][]int {
+	var results [][]int
+	allMatches(re.prog, inputString, n, func(caps []int) {
+		results = append(results, caps)
+	})
+	return results
+}
+
+// FindAllOverlapping returns the byte offsets of the match that starts at each character of the input,
+// the overlapping ones included, e.g., `aa` in "aaaa" gives [0 2], [1 3] and [2 4]. the groups are
+// laid out as in FindSubmatchIndex, and at most 'n' matches are returned unless 'n' is negative.
+// the searches from all the starts are run together in a single pass and merged once they go the same
+// way, which takes O(n*m) time when they soon do, e.g., for `a.*b`. a pattern that needs backtracking,
+// or is set to EngineBacktrack, is searched again from each start and can take quadratic time
+func (re *Regexp) FindAllOverlapping(inputString string, n int) [][]int {
+	if ruledOut(re.prog, inputString) {
+		return nil
+	}
+	var results [][]int
+	if re.prog.needsBacktrack || re.prog.engine == EngineBacktrack {
+		for pos := 0; pos <= len(inputString) && (n < 0 || len(results) < n); {
+			// the leftmost match is the one the matcher prefers among those starting where it starts
+			caps := match(re.prog, inputString, pos)
+			if caps == nil {
+				break
+			}
+			results = append(results, caps)
+			_, size := getChar(inputString, caps[0])
+			pos = caps[0] + size
+		}
+		return results
+	}
+	results = overlappingMatches(re.prog, inputString)
+	if n >= 0 && len(results) > n {
+		results = results[:n:n]
+	}
+	if len(results) == 0 {
+		return nil
 	}
 	return results
 }
+
 func Check(regexString string, inputString string) (Result, *RegexError) {
 	compiledNfa, err := Compile(regexString)
 	if err != nil {
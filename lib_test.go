@@ -0,0 +1,46 @@
+package goregex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnicodeWord(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		ascii   [][]int // FindAllIndex without UnicodeWord
+		unicode [][]int // and with it
+	}{
+		{`\b\w+\b`, "café au lait", [][]int{{0, 3}, {6, 8}, {9, 13}}, [][]int{{6, 8}, {9, 13}}},
+		{`\bé`, "é xé", [][]int{{4, 6}}, [][]int{{0, 2}}},
+		{`a\B`, "aé a", nil, [][]int{{0, 1}}},
+		{`x\b`, "x٣ x", [][]int{{0, 1}, {4, 5}}, [][]int{{4, 5}}},
+		{`\bжук\b`, "жук жуки", nil, [][]int{{0, 6}}},
+		// \w stays ascii
+		{`\w+`, "naïve", [][]int{{0, 2}, {4, 6}}, [][]int{{0, 2}, {4, 6}}},
+	}
+	for _, test := range tests {
+		for _, e := range engines {
+			for _, options := range []Options{0, UnicodeWord} {
+				re, err := CompileWithOptions(test.pattern, options)
+				if err != nil {
+					t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+				}
+				if err := re.SetEngine(e.engine); err != nil {
+					t.Fatalf("%s: SetEngine for %q: %s", e.name, test.pattern, err.Render())
+				}
+				want := test.ascii
+				if options == UnicodeWord {
+					want = test.unicode
+				}
+				if got := re.FindAllIndex(test.input, -1); !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: %q.FindAllIndex(%q) with options %d = %v, want %v", e.name, test.pattern, test.input, options, got, want)
+				}
+				if got := re.MatchString(test.input); got != (want != nil) {
+					t.Errorf("%s: %q.MatchString(%q) with options %d = %v, want %v", e.name, test.pattern, test.input, options, got, want != nil)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package goregex
+
+// the lookarounds of the patterns that don't need backtracking. a lookaround throws its
+// captures away, so without backreferences whether it holds only depends on the position
+// and its body is matched as a set of states, the way the pike vm does, never trying a path twice.
+// a lookaround with a bounded length only goes through a few characters, a lookahead without one
+// could go until the end of the input from every position: whether it holds is worked out for
+// all the positions at once by going through the input backwards, and kept for the next searches
+
+// what's known about the lookarounds over one input, kept along with it
+type lookaroundCache struct {
+	stateCount int
+	// whether the body of a lookahead without a bounded length matches from each byte offset
+	ahead map[*lookaround][]bool
+	free  []*stateSet
+}
+
+func newLookaroundCache(stateCount int) *lookaroundCache {
+	return &lookaroundCache{
+		stateCount: stateCount,
+		ahead:      map[*lookaround][]bool{},
+	}
+}
+
+// states of the NFA at some position, a state is only in once
+type stateSet struct {
+	states []*State
+	mark   []int // generation in which each state was last added
+	gen    int
+	stack  []*State // scratch space for closeOver
+}
+
+func (s *stateSet) clear() {
+	s.states = s.states[:0]
+	s.gen++
+}
+
+func (s *stateSet) has(state *State) bool {
+	return s.mark[state.id] == s.gen
+}
+
+func (s *stateSet) add(state *State) {
+	s.mark[state.id] = s.gen
+	s.states = append(s.states, state)
+}
+
+// a cleared set, handed back with put. the lookarounds can be nested so several are in use at once
+func (c *lookaroundCache) get() *stateSet {
+	if len(c.free) == 0 {
+		return &stateSet{
+			mark: make([]int, c.stateCount),
+			gen:  1,
+		}
+	}
+	set := c.free[len(c.free)-1]
+	c.free = c.free[:len(c.free)-1]
+	set.clear()
+	return set
+}
+
+func (c *lookaroundCache) put(set *stateSet) {
+	c.free = append(c.free, set)
+}
+
+// whether the lookaround 'l' holds at the byte offset 'pos'
+func lookaroundHoldsAt[T inputText](c *lookaroundCache, l *lookaround, input T, pos int) bool {
+	var matched bool
+	switch {
+	case l.behind:
+		matched = behindMatches(c, l, input, pos)
+	case l.maxLen == quantInfinity:
+		table, found := c.ahead[l]
+		if !found {
+			table = aheadTable(c, l, input)
+			c.ahead[l] = table
+		}
+		matched = table[pos]
+	default:
+		matched = aheadMatches(c, l, input, pos)
+	}
+	return matched != l.negated
+}
+
+// whether the state can be entered at the byte offset 'pos', its anchors and lookaround hold there
+func enterable[T inputText](c *lookaroundCache, s *State, input T, pos int) bool {
+	return assertionsHold(s, input, pos) && (s.lookaround == nil || lookaroundHoldsAt(c, s.lookaround, input, pos))
+}
+
+// add 's' and what it reaches by epsilon edges at the byte offset 'pos' to 'set',
+// reports whether the end of the lookaround body was reached
+func closeOver[T inputText](c *lookaroundCache, set *stateSet, s *State, input T, pos int) bool {
+	found := false
+	stack := append(set.stack[:0], s)
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if set.has(s) || !enterable(c, s, input, pos) {
+			continue
+		}
+		set.add(s)
+		if s.lookaroundExit {
+			found = true
+			continue
+		}
+		stack = append(stack, s.epsilon...)
+	}
+	set.stack = stack
+	return found
+}
+
+// whether the body of a lookahead with a bounded length matches from the byte offset 'pos'
+func aheadMatches[T inputText](c *lookaroundCache, l *lookaround, input T, pos int) bool {
+	here, next := c.get(), c.get()
+	defer c.put(here)
+	defer c.put(next)
+	if closeOver(c, here, l.body, input, pos) {
+		return true
+	}
+	for len(here.states) > 0 && pos < len(input) {
+		ch, size := getChar(input, pos)
+		next.clear()
+		for _, s := range here.states {
+			if target := s.step(ch); target != nil && closeOver(c, next, target, input, pos+size) {
+				return true
+			}
+		}
+		pos += size
+		here, next = next, here
+	}
+	return false
+}
+
+// whether the body of a lookbehind matches from somewhere before the byte offset 'pos'
+// up to 'pos' exactly. every place it can start from is added as the characters are read
+func behindMatches[T inputText](c *lookaroundCache, l *lookaround, input T, pos int) bool {
+	// starts[k] is 'k' characters before 'pos'
+	starts := []int{pos}
+	for len(starts) <= l.maxLen && starts[len(starts)-1] > 0 {
+		last := starts[len(starts)-1]
+		_, size := decodeLastRune(input[:last])
+		starts = append(starts, last-size)
+	}
+
+	here, next := c.get(), c.get()
+	defer c.put(here)
+	defer c.put(next)
+	atEnd := false // whether the body got to its end at starts[k]
+	for k := len(starts) - 1; k >= 0; k-- {
+		at := starts[k]
+		if k >= l.minLen && closeOver(c, here, l.body, input, at) {
+			atEnd = true
+		}
+		if k == 0 {
+			break
+		}
+		ch, size := getChar(input, at)
+		next.clear()
+		atEnd = false
+		for _, s := range here.states {
+			if target := s.step(ch); target != nil && closeOver(c, next, target, input, at+size) {
+				atEnd = true
+			}
+		}
+		here, next = next, here
+	}
+	return atEnd
+}
+
+// whether the body of a lookahead without a bounded length matches from each byte offset.
+// going from the end of the input to its start, the states that get to the end of the body
+// from a position are the ones that consume the character there into a state that gets to the
+// end from the next position, the end itself, and what leads to those by epsilon edges
+func aheadTable[T inputText](c *lookaroundCache, l *lookaround, input T) []bool {
+	states := bodyStates(l.body)
+	leadingTo := make([][]*State, c.stateCount)
+	for _, s := range states {
+		for _, target := range s.epsilon {
+			leadingTo[target.id] = append(leadingTo[target.id], s)
+		}
+	}
+
+	table := make([]bool, len(input)+1)
+	here, after := c.get(), c.get()
+	defer c.put(here)
+	defer c.put(after)
+	var queue []*State
+	for pos := len(input); ; {
+		ch, _ := getChar(input, pos)
+		here.clear()
+		for _, s := range states {
+			reaches := s.lookaroundExit
+			if !reaches && pos < len(input) {
+				target := s.step(ch)
+				reaches = target != nil && after.has(target)
+			}
+			if reaches && enterable(c, s, input, pos) {
+				here.add(s)
+				queue = append(queue, s)
+			}
+		}
+		for len(queue) > 0 {
+			s := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			for _, from := range leadingTo[s.id] {
+				if !here.has(from) && enterable(c, from, input, pos) {
+					here.add(from)
+					queue = append(queue, from)
+				}
+			}
+		}
+		table[pos] = here.has(l.body)
+
+		if pos == 0 {
+			break
+		}
+		_, size := decodeLastRune(input[:pos])
+		pos -= size
+		here, after = after, here
+	}
+	return table
+}
+
+// the states of a lookaround body, the bodies of the lookarounds nested in it aren't part of it
+func bodyStates(body *State) []*State {
+	states := []*State{body}
+	visited := map[*State]bool{body: true}
+	for i := 0; i < len(states); i++ {
+		s := states[i]
+		next := append([]*State{}, s.epsilon...)
+		for _, targets := range s.transitions {
+			next = append(next, targets...)
+		}
+		for _, class := range s.classes {
+			next = append(next, class.target)
+		}
+		if s.wildcard != nil {
+			next = append(next, s.wildcard)
+		}
+		for _, state := range next {
+			if !visited[state] {
+				visited[state] = true
+				states = append(states, state)
+			}
+		}
+	}
+	return states
+}
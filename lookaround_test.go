@@ -0,0 +1,76 @@
+package goregex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookarounds(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		want    [][]int
+	}{
+		{`foo(?=bar)`, "foobar foobaz", [][]int{{0, 3}}},
+		{`foo(?!bar)`, "foobar foobaz", [][]int{{7, 10}}},
+		{`(?<=\$)\d+`, "$12 and 34 $5", [][]int{{1, 3}, {12, 13}}},
+		{`(?<!\$)\b\d+`, "$12 and 34 $5", [][]int{{8, 10}}},
+		{`(?<=a|bc)d`, "ad bcd cd", [][]int{{1, 2}, {5, 6}}},
+		{`(?<=^|,)\w+`, "a,bc,,d", [][]int{{0, 1}, {2, 4}, {6, 7}}},
+		{`\b(?!un)\w+`, "undo redo unit", [][]int{{5, 9}}},
+		{`a(?=$)`, "aa", [][]int{{1, 2}}},
+		{`(?=\w+(?<=x))\w`, "abx cd", [][]int{{0, 1}, {1, 2}, {2, 3}}},
+		{`(?=.*c)a`, "a ab abc a", [][]int{{0, 1}, {2, 3}, {5, 6}}},
+		{`(?!.*c)a`, "a ab abc a", [][]int{{9, 10}}},
+		{`(?<!a)(?=b)`, "bab", [][]int{{0, 0}}},
+		{`(?i)(?<=é)x`, "Éx éX ex", [][]int{{2, 3}, {6, 7}}},
+	}
+	for _, test := range tests {
+		for _, e := range engines {
+			re, err := Compile(test.pattern)
+			if err != nil {
+				t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+			}
+			if err := re.SetEngine(e.engine); err != nil {
+				t.Fatalf("%s: SetEngine for %q: %s", e.name, test.pattern, err.Render())
+			}
+			if got := re.FindAllIndex(test.input, -1); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s: %q.FindAllIndex(%q) = %v, want %v", e.name, test.pattern, test.input, got, test.want)
+			}
+		}
+	}
+}
+
+// these went through the backtracking matcher, taking minutes or overflowing the stack
+func TestLookaroundLongInputs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		matches int
+	}{
+		{`(?=(a|a)*c)a`, strings.Repeat("a", 24), 0},
+		{`(?=(a|a)*c)a`, strings.Repeat("a", 24) + "c", 24},
+		{`(?=.*abc$)x`, strings.Repeat("x", 33000), 0},
+		{`(?=.*abc$)x`, strings.Repeat("x", 33000) + "abc", 33000},
+		{`(?=a*c)a`, strings.Repeat("a", 500000), 0},
+		{`(?=a*c)a`, strings.Repeat("a", 500000) + "c", 500000},
+	}
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+		}
+		start := time.Now()
+		if got := re.MatchString(test.input); got != (test.matches > 0) {
+			t.Errorf("%q.MatchString(%d bytes) = %v, want %v", test.pattern, len(test.input), got, test.matches > 0)
+		}
+		if got := len(re.FindAllIndex(test.input, -1)); got != test.matches {
+			t.Errorf("%q.FindAllIndex(%d bytes) found %d matches, want %d", test.pattern, len(test.input), got, test.matches)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%q on %d bytes took %v", test.pattern, len(test.input), elapsed)
+		}
+	}
+}
@@ -2,43 +2,95 @@ package goregex
 
 import (
 	"fmt"
+	"strconv"
 )
 
 type group struct{
 	names []string
+	index   int // group number, resolved from the numeric name when the program is built
 	start   bool
 	end     bool
 }
 
 type backreference struct{
 	name string
+	index  int // group number of 'name', resolved when the program is built
+	foldCase bool
 	target *State
 }
 
+// matched on its own, only the first way the body matches is tried: when what
+// follows fails, the matcher doesn't come back for another one, e.g., fewer repetitions
+type atomicGroup struct{
+	body   *State
+	target *State
+}
+
+// zero width assertion that what comes right after (or before) the position matches the body,
+// or doesn't when negated. the body is matched on its own and its captures are thrown away
+type lookaround struct{
+	body    *State
+	negated bool
+	behind  bool
+	minLen  int // characters a lookbehind can go back, the body has to end right at the position
+	maxLen  int // quantInfinity for a lookahead without a bounded length
+}
+
+// transition taken when the character is in the set
+type classTransition struct{
+	set     charset
+	target  *State
+}
+func (c *classTransition) matches(ch rune) bool {
+	return c.set.contains(ch)
+}
+
+// each kind of edge is kept apart, 'transitions' only holds the literal characters
+// so that no input character can be mistaken for an epsilon or a wildcard move
 type State struct{
+	id            int // position of the state in program.states
 	start         bool
 	terminal      bool
-	endOfText     bool
-	startOfText   bool
-	transitions   map[uint8][]*State
+	assertions    assertion // checked whenever the state is entered
+	transitions   map[rune][]*State
+	classes       []*classTransition
+	epsilon       []*State
+	wildcard      *State
 	groups        []*group
 	backreference *backreference
+	atomic        *atomicGroup
+	atomicExit    bool // where the body of an atomic group ends
+	lookaround    *lookaround // checked whenever the state is entered, like the anchors
+	lookaroundExit bool // where the body of a lookaround ends
 }
 
+// zero width conditions on the position, several of them can be set on a state
+type assertion uint16
+
 const (
-	epsilonChar = 0
-	startOfText = 1
-	endOfText   = 2
-	anyChar     = 3
-	newline     = 10
+	assertTextStart      assertion = 1 << iota // \A, or ^ outside of multiline mode
+	assertTextEnd                              // \z, or $ outside of multiline mode
+	assertLineStart                            // ^ in multiline mode, at the text start or after a newline
+	assertLineEnd                              // $ in multiline mode, at the text end or before a newline
+	assertTextEndNewline                       // \Z, at the text end or before a newline that ends the text
+	assertWordBoundary                         // \b, between a word character and something else
+	assertNotWordBoundary                      // \B, anywhere \b isn't
+	assertUnicodeWord                          // \b and \B take any unicode letter or digit as a word character
+)
+
+// text boundaries reported by getChar, negative so they never collide with a code point
+const (
+	startOfText = -1
+	endOfText   = -2
+	newline     = '\n'
 )
 ////////////////////////////
 func tokenToNfa(token rgToken,parCtx *parsingContext,startFrom *State)(*State ,*State,*RegexError){
 	switch token.tokenType{
 	case literal:
-		value:=token.value.(uint8)
+		value:=token.value.(rune)
 		to:=&State{
-			transitions: map[uint8][]*State{},
+			transitions: map[rune][]*State{},
 		}
 		startFrom.transitions[value]=[]*State{to}
 		return startFrom,to,nil
@@ -46,174 +98,203 @@ func tokenToNfa(token rgToken,parCtx *parsingContext,startFrom *State)(*State ,*
 		return handleQuantifier(token,parCtx,startFrom)
 	case wildcard:
 		to:= &State{
-			transitions: map[uint8][]*State{},
+			transitions: map[rune][]*State{},
 		}
-		startFrom.transitions[anyChar]=[]*State{to}
+		startFrom.wildcard=to
 		return startFrom,to,nil
 	case or:
-		value :=token.value.([]rgToken)
-		_,end1,err:=tokenToNfa(value[0],parCtx,startFrom)
-		if err!=nil{
-			return nil,nil,err
-		}
-		_,end2,err:=tokenToNfa(value[1],parCtx,startFrom)
-		if err!=nil{
-			return nil,nil,err
-		}
+		branches :=token.value.([]rgToken)
 		to:=&State{
-			transitions: map[uint8][]*State{},
+			transitions: map[rune][]*State{},
+		}
+		// every branch hangs off startFrom by an epsilon of its own, in order,
+		// so the leftmost branch that leads to a match wins
+		for _, branch:=range branches{
+			_,end,err:=tokenToNfa(branch,parCtx,startFrom)
+			if err!=nil{
+				return nil,nil,err
+			}
+			end.epsilon=append(end.epsilon, to)
 		}
-		end1.transitions[epsilonChar]=append(end1.transitions[epsilonChar], to)
-		end2.transitions[epsilonChar]=append(end1.transitions[epsilonChar], to)
 		return startFrom,to,nil
 	case groupCaptured:
 		v:=token.value.(groupPayload)
-		start,end,err:=tokenToNfa(v.token[0],parCtx,&State{
-			transitions: map[uint8][]*State{},
-		})
-
-		if err !=nil{
-			return nil,nil,err
+		start:=&State{
+			transitions: map[rune][]*State{},
 		}
-		for i:=1;i<len(v.token);i++{
+		end:=start
+		for i:=0;i<len(v.token);i++{
 			_, endNext,err:=tokenToNfa(v.token[i],parCtx,end)
 			if err!=nil{
 				return nil, nil, err
 			}
 			end=endNext
 		}
-		groupNameNumeric:= fmt.Sprintf("%d",parCtx.nextGroup())
+		groupNameNumeric:= fmt.Sprintf("%d",v.index)
 		groupNameUserSet := v.name
 		groupNames := []string{groupNameNumeric}
-		parCtx.capturedGroups[groupNameNumeric] = true
 		if groupNameUserSet != "" {
 			groupNames = append(groupNames, groupNameUserSet)
-			parCtx.capturedGroups[groupNameUserSet] = true
 		}
 
-		if startFrom.groups != nil {
-			startFrom.groups = append(startFrom.groups, &group{
-				names: groupNames,
-				start: true,
-			})
-		} else {
-			startFrom.groups = []*group{{
+		// the group boundaries get states of their own, so that the
+		// captures aren't touched by other paths going through startFrom or end
+		open := &State{
+			transitions: map[rune][]*State{},
+			epsilon:     []*State{start},
+			groups: []*group{{
 				names: groupNames,
 				start: true,
-			}}
+			}},
 		}
-
-		if end.groups != nil {
-			end.groups = append(end.groups, &group{
-				names: groupNames,
-				end:   true,
-			})
-		} else {
-			end.groups = []*group{{
+		closing := &State{
+			transitions: map[rune][]*State{},
+			groups: []*group{{
 				names: groupNames,
 				end:   true,
-			}}
+			}},
 		}
+		end.epsilon = append(end.epsilon, closing)
 
-		startFrom.transitions[epsilonChar] = append(startFrom.transitions[epsilonChar], start)
-		return startFrom, end, nil
+		startFrom.epsilon = append(startFrom.epsilon, open)
+		return startFrom, closing, nil
 	case groupUncaptured:
 		values:=token.value.([]rgToken)
 
 		if len(values)==0{
 			end:=&State{
-				transitions: map[uint8][]*State{},
+				transitions: map[rune][]*State{},
 			}
-			startFrom.transitions[epsilonChar]=append(startFrom.transitions[epsilonChar], end)
+			startFrom.epsilon=append(startFrom.epsilon, end)
 			return startFrom,end,nil
 		}
 		start ,end,err:= tokenToNfa(values[0],parCtx,&State{
-			transitions: map[uint8][]*State{},
+			transitions: map[rune][]*State{},
 		})
 		if err!=nil{
 			return nil,nil,err
 		}
 		
-		for i := 0; i < len(values); i++ {
+		for i := 1; i < len(values); i++ {
 			_,endNext,err :=tokenToNfa(values[i],parCtx,end)
 			if err!=nil{
 				return nil,nil,err
 			}
 			end=endNext
 		}
-		startFrom.transitions[epsilonChar]=append(startFrom.transitions[epsilonChar], start)
+		startFrom.epsilon=append(startFrom.epsilon, start)
 		return startFrom,end,nil
-	case bracket:
-		constructToken:= token.value.(map[uint8]bool)
-		to:=&State{
-			transitions: map[uint8][]*State{},
+	case lookahead, lookbehind:
+		payload:=token.value.(lookaroundPayload)
+		body:=&State{
+			transitions: map[rune][]*State{},
 		}
-		for ch:=range constructToken{
-			startFrom.transitions[ch]=[]*State{to}
-		}
-		return 	startFrom,to,nil
-	case bracketNot:
-		constructTokens := token.value.(map[uint8]bool)
-
-		to := &State{
-			transitions: map[uint8][]*State{},
+		_, bodyEnd, err:=tokenToNfa(rgToken{tokenType: groupUncaptured, value: payload.token},parCtx,body)
+		if err!=nil{
+			return nil,nil,err
 		}
-
-		deadEnd := &State{
-			transitions: map[uint8][]*State{},
+		bodyEnd.epsilon=append(bodyEnd.epsilon, &State{
+			transitions:    map[rune][]*State{},
+			lookaroundExit: true,
+		})
+		to:=&State{
+			transitions: map[rune][]*State{},
+			lookaround: &lookaround{
+				body:    body,
+				negated: payload.negated,
+				behind:  token.tokenType==lookbehind,
+				minLen:  payload.minLen,
+				maxLen:  payload.maxLen,
+			},
 		}
-
-		for ch := range constructTokens {
-			startFrom.transitions[ch] = []*State{deadEnd}
+		startFrom.epsilon=append(startFrom.epsilon, to)
+		return startFrom,to,nil
+	case groupAtomic:
+		return atomicToNfa(func(body *State)(*State,*State,*RegexError){
+			return tokenToNfa(rgToken{tokenType: groupUncaptured, value: token.value},parCtx,body)
+		},startFrom)
+	case bracket:
+		set := token.value.(charset)
+		to := &State{
+			transitions: map[rune][]*State{},
 		}
-		startFrom.transitions[anyChar] = []*State{to}
-
+		startFrom.classes = append(startFrom.classes, &classTransition{
+			set:    set,
+			target: to,
+		})
 		return startFrom, to, nil
-	case textBeginning:
+	case textBeginning, textEnd, wordBoundary:
+		// the anchor gets a state of its own, so that it doesn't
+		// hold back the other edges leaving startFrom, e.g., a loop
 		to := &State{
-			transitions: map[uint8][]*State{},
+			transitions: map[rune][]*State{},
+			assertions:  token.value.(assertion),
 		}
-		startFrom.startOfText = true
-		startFrom.transitions[epsilonChar] = append(startFrom.transitions[epsilonChar], to)
+		startFrom.epsilon = append(startFrom.epsilon, to)
 		return startFrom, to, nil
-	case textEnd:
-		startFrom.endOfText = true
-		return startFrom, startFrom, nil
 	case backReference:
-		groupName := token.value.(string)
-		if _, ok := parCtx.capturedGroups[groupName]; !ok {
-			return nil, nil, &RegexError{
-				Code:    CompilationError,
-				Message: fmt.Sprintf("Group (%s) does not exist", groupName),
-			}
-		}
+		payload := token.value.(backrefPayload)
 		to := &State{
-			transitions: map[uint8][]*State{},
+			transitions: map[rune][]*State{},
 		}
 
 		startFrom.backreference = &backreference{
-			name:   groupName,
-			target: to,
+			name:     payload.name,
+			foldCase: payload.foldCase,
+			target:   to,
 		}
 
 		return startFrom, to, nil
 	default:
 		return nil, nil, &RegexError{
 			Code:    CompilationError,
+			Kind:    ErrInternal,
 			Message: fmt.Sprintf("unrecognized token: %+v", token),
 		}
 	} 
 }
+// the states built by 'build' from a fresh state, wrapped in an atomic group
+func atomicToNfa(build func(startFrom *State)(*State,*State,*RegexError), startFrom *State)(*State,*State,*RegexError){
+	body:=&State{
+		transitions: map[rune][]*State{},
+	}
+	_, bodyEnd, err:=build(body)
+	if err!=nil{
+		return nil,nil,err
+	}
+	bodyEnd.epsilon=append(bodyEnd.epsilon, &State{
+		transitions: map[rune][]*State{},
+		atomicExit:  true,
+	})
+	to:=&State{
+		transitions: map[rune][]*State{},
+	}
+	// the group gets a state of its own, startFrom may have other edges
+	entry:=&State{
+		transitions: map[rune][]*State{},
+		atomic: &atomicGroup{
+			body:   body,
+			target: to,
+		},
+	}
+	startFrom.epsilon=append(startFrom.epsilon, entry)
+	return startFrom,to,nil
+}
+
 func handleQuantifier(token rgToken,parCtx *parsingContext, startFrom *State)(*State,*State,*RegexError){
 	payload:=token.value.(quantPayload)
+	if payload.possessive{
+		// as many repetitions as possible and never fewer, i.e., an atomic group around the greedy quantifier
+		payload.possessive=false
+		payload.lazy=false
+		return atomicToNfa(func(body *State)(*State,*State,*RegexError){
+			return handleQuantifier(rgToken{tokenType: quantifier, value: payload},parCtx,body)
+		},startFrom)
+	}
 	min:= payload.min
 	max:= payload.max
 	to:= &State{
-		transitions: map[uint8][]*State{},
-	}
-
-	if min==0{
-		startFrom.transitions[epsilonChar]= append(startFrom.transitions[epsilonChar], to)
+		transitions: map[rune][]*State{},
 	}
 
 	var total int
@@ -227,51 +308,115 @@ func handleQuantifier(token rgToken,parCtx *parsingContext, startFrom *State)(*S
 			total=min
 		}
 	}
+	if max==0{
+		// never repeated, the operand isn't built at all
+		startFrom.epsilon=append(startFrom.epsilon, to)
+		return startFrom,to,nil
+	}
 	var value=payload.value
 	previousStart,previousEnd,err:= tokenToNfa(value,parCtx, &State{
-		transitions: map[uint8][]*State{},
+		transitions: map[rune][]*State{},
 	})
 
 	if err!=nil{
 		return nil,nil,err
 	}
-	startFrom.transitions[epsilonChar]=append(startFrom.transitions[epsilonChar], previousStart)
+	// edges are added in priority order, the matchers try another
+	// repetition before giving up on it which makes the quantifier greedy,
+	// a lazy one puts the way out first
+	if min==0 && max==quantInfinity && !nullable(value){
+		// one state both enters and repeats the loop, so a loop around this one that comes
+		// back to it without consuming anything finds it already visited, as in RE2
+		loop:=&State{
+			transitions: map[rune][]*State{},
+		}
+		if payload.lazy{
+			loop.epsilon=append(loop.epsilon, to, previousStart)
+		}else{
+			loop.epsilon=append(loop.epsilon, previousStart, to)
+		}
+		startFrom.epsilon=append(startFrom.epsilon, loop)
+		previousEnd.epsilon=append(previousEnd.epsilon, loop)
+		return startFrom,to,nil
+	}
+	if min==0 && payload.lazy{
+		startFrom.epsilon= append(startFrom.epsilon, to)
+	}
+	startFrom.epsilon=append(startFrom.epsilon, previousStart)
+	if min==0 && !payload.lazy{
+		startFrom.epsilon= append(startFrom.epsilon, to)
+	}
 
 	for i:= 2;i<=total;i++{
 		start,end,err := tokenToNfa(value,parCtx,&State{
-			transitions: map[uint8][]*State{},
+			transitions: map[rune][]*State{},
 		})
 		if err!=nil{
 			return nil,nil,err
 		}
 
-		previousEnd.transitions[epsilonChar]=append(previousEnd.transitions[epsilonChar], start)
+		if i>min && payload.lazy{
+			previousEnd.epsilon=append(previousEnd.epsilon, to)
+		}
+		previousEnd.epsilon=append(previousEnd.epsilon, start)
+		if i>min && !payload.lazy{
+			previousEnd.epsilon=append(previousEnd.epsilon, to)
+		}
 
 		previousStart=start
 		previousEnd=end
-
-		if i>min{
-			start.transitions[epsilonChar]=append(start.transitions[epsilonChar], to)
-		}
-
 	}
-	previousEnd.transitions[epsilonChar]=append(previousEnd.transitions[epsilonChar], to)
+	previousEnd.epsilon=append(previousEnd.epsilon, to)
 	if max == quantInfinity{
-		to.transitions[epsilonChar] = append(to.transitions[epsilonChar], previousStart)
+		if payload.lazy{
+			// the edges 'to' gets from what follows have to come first, so the loop can't start from it
+			previousEnd.epsilon = append(previousEnd.epsilon, previousStart)
+		}else{
+			to.epsilon = append(to.epsilon, previousStart)
+		}
 	}
 	return startFrom,to,nil
 }
-////////////////////////////
-func toNfa(parCtx *parsingContext)(*State,*RegexError){
-	token := parCtx.tokens[0]
+// whether 'token' can match without consuming anything
+func nullable(token rgToken) bool {
+	switch token.tokenType {
+	case literal, bracket, wildcard:
+		return false
+	case groupCaptured:
+		return allNullable(token.value.(groupPayload).token)
+	case groupUncaptured, groupAtomic:
+		return allNullable(token.value.([]rgToken))
+	case or:
+		for _, branch := range token.value.([]rgToken) {
+			if nullable(branch) {
+				return true
+			}
+		}
+		return false
+	case quantifier:
+		payload := token.value.(quantPayload)
+		return payload.min == 0 || nullable(payload.value)
+	}
+	// assertions, lookarounds and backreferences, which can refer to an empty group
+	return true
+}
 
-	startState,endState,err := tokenToNfa(token,parCtx,&State{
-		transitions: map[uint8][]*State{},
-	})
-	if err!=nil{
-		return nil,err
+func allNullable(tokens []rgToken) bool {
+	for _, token := range tokens {
+		if !nullable(token) {
+			return false
+		}
 	}
-	for i:=1; i<len(parCtx.tokens);i++{
+	return true
+}
+
+////////////////////////////
+func toNfa(parCtx *parsingContext)(*program,*RegexError){
+	startState:=&State{
+		transitions: map[rune][]*State{},
+	}
+	endState:=startState
+	for i:=0; i<len(parCtx.tokens);i++{
 		_,endNext,err:= tokenToNfa(parCtx.tokens[i],parCtx,endState)
 		if err!=nil{
 			return nil,err
@@ -280,9 +425,8 @@ func toNfa(parCtx *parsingContext)(*State,*RegexError){
 	}
 	start :=&State{
 		start: true,
-		transitions: map[uint8][]*State{
-			epsilonChar: {startState},
-		},
+		transitions: map[rune][]*State{},
+		epsilon:     []*State{startState},
 		groups: []*group{{
 			names: []string{"0"},
 			start: true,
@@ -291,7 +435,7 @@ func toNfa(parCtx *parsingContext)(*State,*RegexError){
 	}
 
 	end := &State{
-		transitions: map[uint8][]*State{},
+		transitions: map[rune][]*State{},
 		terminal: true,
 		groups: []*group{
 			{
@@ -302,7 +446,179 @@ func toNfa(parCtx *parsingContext)(*State,*RegexError){
 		},
 	}
 
-	endState.transitions[epsilonChar]=append(endState.transitions[epsilonChar], end)
-	return start ,nil
+	endState.epsilon=append(endState.epsilon, end)
+	return newProgram(start, *parCtx.groupNames)
+}
+
+// metadata about the compiled NFA shared by the matching engines
+type program struct {
+	start            *State
+	states           []*State       // every state reachable from the start, indexed by State.id
+	groupNames       [][]string     // names of every capturing group, indexed by the group number
+	groupIndex       map[string]int // group number of every name, numeric ones included
+	needsBacktrack   bool // backreferences and atomic groups are beyond the pike vm and the dfa
+	hasBackrefs      bool
+	lookarounds      bool // whether there's a lookaround anywhere in the pattern
+	lookBehind       int  // most characters the anchors and lookbehinds look at before a position
+	lookAhead        int  // most characters they look at from a position on, quantInfinity when unbounded
+	engine           Engine
+	spanOnly         bool // only the bounds of the match are tracked, not the groups
+	dfa              *lazyDFA // nil when the pattern can't be answered by a dfa
+}
+
+// walk the NFA from the start state, numbering the states and resolving the group names.
+// 'names' holds the name of every group as parsed, "" for the unnamed ones
+func newProgram(start *State, names []string) (*program, *RegexError) {
+	groupCount := len(names) - 1
+	prog := &program{
+		start:      start,
+		groupNames: make([][]string, groupCount+1),
+		groupIndex: map[string]int{},
+		// one character on either side for the anchors and \b, \Z needs the one after the next
+		lookBehind: 1,
+		lookAhead:  2,
+	}
+	var backreferences []*backreference
+	// the dfa only knows the characters around a position, \Z and the lookarounds look further
+	lookahead := false
+
+	visited := map[*State]bool{start: true}
+	stack := []*State{start}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		s.id = len(prog.states)
+		prog.states = append(prog.states, s)
+		if s.assertions&assertTextEndNewline != 0 {
+			lookahead = true
+		}
+
+		for _, capturedGroup := range s.groups {
+			index, err := strconv.Atoi(capturedGroup.names[0])
+			if err != nil || index > groupCount {
+				return nil, &RegexError{
+					Code:    CompilationError,
+					Kind:    ErrInternal,
+					Message: fmt.Sprintf("invalid group number (%s)", capturedGroup.names[0]),
+				}
+			}
+			capturedGroup.index = index
+			prog.groupNames[index] = capturedGroup.names
+			for _, name := range capturedGroup.names {
+				prog.groupIndex[name] = index
+			}
+		}
+
+		next := append([]*State{}, s.epsilon...)
+		for _, states := range s.transitions {
+			next = append(next, states...)
+		}
+		for _, class := range s.classes {
+			next = append(next, class.target)
+		}
+		if s.wildcard != nil {
+			next = append(next, s.wildcard)
+		}
+		if s.lookaround != nil {
+			lookahead = true
+			prog.lookarounds = true
+			// the lookarounds can be nested, the sum of their lengths is as far as they can get together
+			switch {
+			case s.lookaround.behind:
+				prog.lookBehind += s.lookaround.maxLen
+			case s.lookaround.maxLen == quantInfinity || prog.lookAhead == quantInfinity:
+				prog.lookAhead = quantInfinity
+			default:
+				prog.lookAhead += s.lookaround.maxLen
+			}
+			next = append(next, s.lookaround.body)
+		}
+		if s.atomic != nil {
+			prog.needsBacktrack = true
+			next = append(next, s.atomic.body, s.atomic.target)
+		}
+		if s.backreference != nil {
+			prog.needsBacktrack = true
+			backreferences = append(backreferences, s.backreference)
+			next = append(next, s.backreference.target)
+		}
+		for _, state := range next {
+			if !visited[state] {
+				visited[state] = true
+				stack = append(stack, state)
+			}
+		}
+	}
+
+	// a group repeated {0} times isn't in the NFA, it's still a group that never matches
+	for index := 1; index <= groupCount; index++ {
+		if prog.groupNames[index] != nil {
+			continue
+		}
+		prog.groupNames[index] = []string{strconv.Itoa(index)}
+		if names[index] != "" {
+			prog.groupNames[index] = append(prog.groupNames[index], names[index])
+		}
+		for _, name := range prog.groupNames[index] {
+			prog.groupIndex[name] = index
+		}
+	}
+
+	prog.hasBackrefs = len(backreferences) > 0
+	for _, ref := range backreferences {
+		ref.index = prog.groupIndex[ref.name]
+	}
+	if !prog.needsBacktrack && !lookahead {
+		prog.dfa = newLazyDFA(start, len(prog.states))
+	}
+	return prog, nil
+}
+
+// fresh capture slots, two for each group, or only for the whole match when the groups
+// aren't tracked, and all of them unset
+func (p *program) newCaps() []int {
+	slots := 2 * len(p.groupNames)
+	if p.spanOnly {
+		slots = 2
+	}
+	caps := make([]int, slots)
+	for i := range caps {
+		caps[i] = -1
+	}
+	return caps
+}
+
+// whether one of the groups of this state is among the first 'slots' capture slots
+func (s *State) capturesInto(slots int) bool {
+	for _, capturedGroup := range s.groups {
+		if 2*capturedGroup.index < slots {
+			return true
+		}
+	}
+	return false
+}
+
+// collect the substrings of the groups that took part in the match
+func (p *program) result(inputString string, caps []int) Result {
+	groups := map[string]string{}
+	if caps == nil {
+		return Result{
+			Matches: false,
+			Groups:  groups,
+		}
+	}
+	for index, names := range p.groupNames {
+		start, end := caps[2*index], caps[2*index+1]
+		if start < 0 || end < start {
+			continue
+		}
+		for _, name := range names {
+			groups[name] = inputString[start:end]
+		}
+	}
+	return Result{
+		Matches: true,
+		Groups:  groups,
+	}
 }
 
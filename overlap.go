@@ -0,0 +1,186 @@
+package goregex
+
+import (
+	"encoding/binary"
+	"slices"
+)
+
+// the overlapping matches are found by running the pike vm from every start at once in a single pass
+// over the input. two searches that have the same threads in the same order at some position go the
+// same way from there on, so they're merged into one and only what their groups captured before that
+// position is kept apart. patterns like `a.*b`, whose searches soon all look alike, take linear time
+// instead of going through the rest of the input again from each start
+
+// a capture slot that wasn't written since the search it's part of was merged
+const inherited = -2
+
+// the searches from one or more starts that went the same way so far, whether or not they had
+// found a match already. the captures of a search that wasn't merged are the ones of its start.
+// in a merged search, slot 0 of the captures is the index of the thread it comes from at the
+// merge point, and the slots that weren't written since are 'inherited'
+type overlapSearch[T inputText] struct {
+	current  *threadList[T]
+	next     *threadList[T]
+	matched  []int
+	sources  []overlapSource[T] // the searches merged into this one, none for a single start
+	mergedAt int
+}
+
+// one of the searches merged into another and the captures of each of its threads at the merge point
+type overlapSource[T inputText] struct {
+	search *overlapSearch[T]
+	caps   [][]int
+}
+
+// the capture slots of the preferred match from each start, in the order of the starts
+func overlappingMatches[T inputText](p *program, input T) [][]int {
+	looks := newWindow(input).lookarounds(p)
+	var free []*threadList[T]
+	newList := func() *threadList[T] {
+		if len(free) == 0 {
+			l := newThreadList[T](len(p.states))
+			l.looks = looks
+			return l
+		}
+		l := free[len(free)-1]
+		free = free[:len(free)-1]
+		l.clear()
+		return l
+	}
+
+	var found [][]int
+	var searches []*overlapSearch[T]
+	byThreads := map[string]int{} // index of the search with the given threads
+	var key []byte
+	for pos := 0; ; {
+		start := &overlapSearch[T]{current: newList(), next: newList(), mergedAt: -1}
+		start.current.add(p.start, input, pos, p.newCaps())
+		searches = append(searches, start)
+
+		clear(byThreads)
+		kept := searches[:0]
+		for _, s := range searches {
+			if len(s.current.threads) == 0 {
+				kept = append(kept, s)
+				continue
+			}
+			key = key[:0]
+			for _, t := range s.current.threads {
+				key = binary.AppendUvarint(key, uint64(t.state.id))
+			}
+			index, seen := byThreads[string(key)]
+			if !seen {
+				byThreads[string(key)] = len(kept)
+				kept = append(kept, s)
+				continue
+			}
+			if kept[index].mergedAt != pos {
+				kept[index] = kept[index].mergeInto(pos)
+			}
+			kept[index].sources = append(kept[index].sources, s.source())
+			free = append(free, s.current, s.next)
+		}
+		searches = kept
+
+		ch, size := getChar(input, pos)
+		kept = searches[:0]
+		for _, s := range searches {
+			s.next.clear()
+			for _, t := range s.current.threads {
+				if t.state.terminal {
+					// the threads after this one have a lower priority, drop them
+					s.matched = t.caps
+					break
+				}
+				if nextState := t.state.step(ch); nextState != nil {
+					s.next.add(nextState, input, pos+size, t.caps)
+				}
+			}
+			if pos >= len(input) || len(s.next.threads) == 0 {
+				found = s.resolve(found)
+				free = append(free, s.current, s.next)
+				continue
+			}
+			s.current, s.next = s.next, s.current
+			kept = append(kept, s)
+		}
+		searches = kept
+
+		if pos >= len(input) {
+			break
+		}
+		pos += size
+	}
+	slices.SortFunc(found, func(a, b []int) int {
+		return a[0] - b[0]
+	})
+	return found
+}
+
+// what the threads of the search had captured, for the search it's merged into
+func (s *overlapSearch[T]) source() overlapSource[T] {
+	caps := make([][]int, len(s.current.threads))
+	for k, t := range s.current.threads {
+		caps[k] = t.caps
+	}
+	return overlapSource[T]{search: s, caps: caps}
+}
+
+// a search that takes over the threads of 's' at the byte offset 'pos', the other searches
+// with the same threads are added to its sources
+func (s *overlapSearch[T]) mergeInto(pos int) *overlapSearch[T] {
+	merged := &overlapSearch[T]{
+		current:  s.current,
+		next:     s.next,
+		sources:  []overlapSource[T]{s.source()},
+		mergedAt: pos,
+	}
+	s.current, s.next = nil, nil
+	for k := range merged.current.threads {
+		caps := make([]int, len(merged.current.threads[k].caps))
+		for i := range caps {
+			caps[i] = inherited
+		}
+		caps[0] = k
+		merged.current.threads[k].caps = caps
+	}
+	return merged
+}
+
+// add the match from each start the search stands for to 'found'. a match found after the searches
+// were merged is the same for all of them, the threads it comes from have a higher priority than
+// whatever each of them had found before. without one, each of the merged searches keeps its own
+func (s *overlapSearch[T]) resolve(found [][]int) [][]int {
+	type pending struct {
+		search *overlapSearch[T]
+		caps   []int // nil for whatever the search found itself
+	}
+	stack := []pending{{s, nil}}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if top.caps == nil {
+			if top.search.matched == nil {
+				for _, source := range top.search.sources {
+					stack = append(stack, pending{source.search, nil})
+				}
+				continue
+			}
+			top.caps = top.search.matched
+		}
+		if top.search.sources == nil {
+			found = append(found, top.caps)
+			continue
+		}
+		for _, source := range top.search.sources {
+			caps := append([]int(nil), source.caps[top.caps[0]]...)
+			for i := 1; i < len(caps); i++ {
+				if top.caps[i] != inherited {
+					caps[i] = top.caps[i]
+				}
+			}
+			stack = append(stack, pending{source.search, caps})
+		}
+	}
+	return found
+}
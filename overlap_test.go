@@ -0,0 +1,74 @@
+package goregex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindAllOverlapping(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		n       int
+		want    [][]int
+	}{
+		{`aa`, "aaaa", -1, [][]int{{0, 2}, {1, 3}, {2, 4}}},
+		{`aa`, "aaaa", 2, [][]int{{0, 2}, {1, 3}}},
+		{`aa`, "aaaa", 0, nil},
+		{`x`, "aaaa", -1, nil},
+		{`a*`, "baé", -1, [][]int{{0, 0}, {1, 2}, {2, 2}, {4, 4}}},
+		{`a.*b`, "aabab", -1, [][]int{{0, 5}, {1, 5}, {3, 5}}},
+		{`(a+)(b)?`, "aab", -1, [][]int{{0, 3, 0, 2, 2, 3}, {1, 3, 1, 2, 2, 3}}},
+		{`(a|ab)(c|bcd)(d*)`, "abcd", -1, [][]int{{0, 4, 0, 1, 1, 4, 4, 4}}},
+		{`(\w)\w*(\w)`, "abc", -1, [][]int{{0, 3, 0, 1, 2, 3}, {1, 3, 1, 2, 2, 3}}},
+		{`(?<=a)b+`, "abbab", -1, [][]int{{1, 3}, {4, 5}}},
+		{`\w+(?=!)`, "ab! c!", -1, [][]int{{0, 2}, {1, 2}, {4, 5}}},
+		{`(a)\1`, "aaa", -1, [][]int{{0, 2, 0, 1}, {1, 3, 1, 2}}},
+	}
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+		}
+		if got := re.FindAllOverlapping(test.input, test.n); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q.FindAllOverlapping(%q, %d) = %v, want %v", test.pattern, test.input, test.n, got, test.want)
+		}
+		if re.SetEngine(EngineBacktrack) != nil {
+			continue
+		}
+		if got := re.FindAllOverlapping(test.input, test.n); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("backtrack: %q.FindAllOverlapping(%q, %d) = %v, want %v", test.pattern, test.input, test.n, got, test.want)
+		}
+	}
+}
+
+// these searched the rest of the input again from every start, taking a minute
+func TestFindAllOverlappingLongInputs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		matches int
+	}{
+		{`a.*b`, strings.Repeat("a", 20000) + "b", 20000},
+		{`a.*b`, strings.Repeat("ab", 10000), 10000},
+		{`(a)(.*)(b)`, strings.Repeat("ab", 10000), 10000},
+	}
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+		}
+		start := time.Now()
+		got := re.FindAllOverlapping(test.input, -1)
+		if len(got) != test.matches {
+			t.Errorf("%q.FindAllOverlapping(%d bytes) found %d matches, want %d", test.pattern, len(test.input), len(got), test.matches)
+		} else if end := got[len(got)-1][1]; end != len(test.input) {
+			t.Errorf("%q.FindAllOverlapping(%d bytes) last match ends at %d, want %d", test.pattern, len(test.input), end, len(test.input))
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%q on %d bytes took %v", test.pattern, len(test.input), elapsed)
+		}
+	}
+}
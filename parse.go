@@ -2,23 +2,29 @@ package goregex
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type rgTokenType uint8
 
 const(
 	literal         rgTokenType = iota // any literal character, e.g., a, b, 1, 2, etc.
-	or                             = iota // |
-	bracket                        = iota // []
-	bracketNot                     = iota // [^]
+	or                             = iota // |, the value holds every branch in order
+	bracket                        = iota // [] or any other class, the value is a charset
 	groupCaptured                  = iota // ()
-	groupUncaptured                = iota // logical group
+	groupUncaptured                = iota // logical group, (?:) included
+	groupAtomic                    = iota // (?>), the value is the same as a logical group's
+	lookahead                      = iota // (?=) or (?!)
+	lookbehind                     = iota // (?<=) or (?<!)
+	wordBoundary                   = iota // \b or \B, the value is the assertion
 	wildcard                       = iota // .
-	textBeginning                  = iota // ^
-	textEnd                        = iota // $
-	backReference                  = iota // $
+	textBeginning                  = iota // ^ or \A, the value is the assertion
+	textEnd                        = iota // $, \z or \Z, the value is the assertion
+	backReference                  = iota // \1 or \k<name>
 	quantifier                     = iota // {m,n} or {m,}, {m}
 )
 // value can be anything 
@@ -31,18 +37,40 @@ type quantPayload struct{
 	min int
 	max int 
 	value rgToken
+	lazy bool // as few repetitions as possible first
+	possessive bool // as many repetitions as possible, never giving any back
+}
+
+type lookaroundPayload struct{
+	token []rgToken
+	negated bool
+	minLen int // length of what the body matches, in characters
+	maxLen int // quantInfinity when unbounded, which only a lookahead can be
+}
+
+type backrefPayload struct{
+	name string
+	foldCase bool
+}
+
+// inclusive range of code points
+type runeRange struct {
+	lo rune
+	hi rune
 }
 
 type groupPayload struct{
 	token []rgToken
 	name string
+	index int // group number, groups are numbered in the order of their opening parenthesis
 }
-// store position and tokens also stored captured group and encountered group count
+// store position and tokens also stored captured group and the names of the groups met so far,
+// the names are shared with the contexts of the nested groups so the numbering carries on
 type parsingContext struct{
 	pos int 
 	tokens []rgToken
-	groupCount uint8
-	capturedGroups map[string]bool
+	groupNames *[]string // "" for unnamed groups, the whole match is group 0
+	flags Options
 }
 
 // methods of parsing context 
@@ -51,10 +79,47 @@ type parsingContext struct{
 func (p* parsingContext)loc() int{
 	return p.pos
 }
-// updates groupcount and return gc
-func (p* parsingContext) nextGroup() uint8{
-	p.groupCount++
-	return p.groupCount
+// registers a new group and returns its number
+func (p* parsingContext) nextGroup(name string) int{
+	*p.groupNames = append(*p.groupNames, name)
+	return len(*p.groupNames)-1
+}
+// whether a group with that number or name has been opened so far
+func (p* parsingContext) hasGroup(name string) bool{
+	if index, err := strconv.Atoi(name); err == nil {
+		return index > 0 && index < len(*p.groupNames)
+	}
+	return name != "" && slices.Contains(*p.groupNames, name)
+}
+// push a literal character, under the case insensitive flag
+// a character with other cases becomes a class of all of them
+func (p* parsingContext) pushLiteral(ch rune){
+	if p.flags&CaseInsensitive!=0{
+		if orbit:=foldOrbit(ch); len(orbit)>1{
+			ranges:=make([]runeRange, 0, len(orbit))
+			for _, other:=range orbit{
+				ranges=append(ranges, runeRange{lo: other, hi: other})
+			}
+			p.push(rgToken{
+				tokenType: bracket,
+				value: newCharset(ranges...),
+			})
+			return
+		}
+	}
+	p.push(rgToken{
+		tokenType: literal,
+		value: ch,
+	})
+}
+// context for what's inside of a group, starting at the current position
+func (p* parsingContext) nested() parsingContext{
+	return parsingContext{
+		pos: p.loc(),
+		tokens: []rgToken{},
+		groupNames: p.groupNames,
+		flags: p.flags,
+	}
 }
 // advance to next position , iterator
 func (p* parsingContext) adv() int {
@@ -69,6 +134,10 @@ func (p* parsingContext) advTo(pos int){
 func (p* parsingContext) push(token rgToken){
 	p.tokens = append(p.tokens, token)
 }
+// decode the code point at byte offset pos, returns it along with its width in bytes
+func runeAt(s string, pos int) (rune, int) {
+	return utf8.DecodeRuneInString(s[pos:])
+}
 // remove last 'n' tokens from tokens[] of parseContext
 func (p* parsingContext) remLast(count int)[]rgToken {
 	toRem :=p.tokens[len(p.tokens)-count:]
@@ -78,22 +147,22 @@ func (p* parsingContext) remLast(count int)[]rgToken {
 /////////////////////////////////////
 // checker functions
 //aplha check
-func isAlphaLow (ch uint8) bool{
+func isAlphaLow (ch rune) bool{
 	return ch>= 'a' && ch<='z'
 }
 //aplha check
-func isAlphaUp (ch uint8) bool{
+func isAlphaUp (ch rune) bool{
 	return ch>= 'A' && ch<='Z'
 }
 // num check 
-func isDig (ch uint8) bool{
+func isDig (ch rune) bool{
 	return ch>='0'&&ch<='9'
 }
 
 // charecter maps to find chaecter that defines the regex 
 // eg r'@gmail.com$ dollar should be kept in map so parser can differentiate
 // from rest of the string ie. @gmail.com
-var specialChar =map[uint8]bool{
+var specialChar =map[rune]bool{
 	'&':  true,
 	'*':  true,
 	' ':  true,
@@ -113,7 +182,7 @@ var specialChar =map[uint8]bool{
 	'/':  true,
 }
 // regex relevant char
-var mustBeEscapedChar = map[uint8]bool{
+var mustBeEscapedChar = map[rune]bool{
 	'[':  true,
 	'\\': true,
 	'^':  true,
@@ -129,128 +198,281 @@ var mustBeEscapedChar = map[uint8]bool{
 	'}':  true,
 }
 // is in special char map or not
-func isSpecial(ch uint8)bool {
+func isSpecial(ch rune)bool {
 	_, flag := specialChar[ch]
 	return flag
 }
-// check all condition aplha num special char
-func isLiteral(ch uint8)bool{
-	 return isAlphaLow(ch)||isAlphaUp(ch)||isDig(ch)||isSpecial(ch)
+// check all condition aplha num special char, anything outside of ascii is a literal too
+func isLiteral(ch rune)bool{
+	 return isAlphaLow(ch)||isAlphaUp(ch)||isDig(ch)||isSpecial(ch)||ch>=utf8.RuneSelf
 }
 // check for '.'
-func isWild(ch uint8)bool{
+func isWild(ch rune)bool{
 	return ch=='.'
 }
 
 const quantInfinity =-1
 
+// every repetition is a copy of the repeated states, so the bounds are capped
+const maxRepeat = 1000
+
 // map quant symbols as {}range eg + is 1 to inf , * is 0 to inf
-var quantToCurly= map[uint8][]int{
+var quantToCurly= map[rune][]int{
 	'*':{0,quantInfinity},
 	'+':{1,quantInfinity},
 	'?':{0,1},
 }
 // is present as quant symbol - *,+,?
-func isQuantifier(ch uint8)bool{
+func isQuantifier(ch rune)bool{
 	_,ok:=quantToCurly[ch]
 	return ok
 }
 
 //parce [] brackets , parses for inside content of []
-func parseBracket(regString string,parCtx *parsingContext) *RegexError{
-	var tokenType rgTokenType
+// parse a bracket expression, the position being right after its '[', and leave it on the closing ']'
+func parseBracket(regString string, parCtx *parsingContext) *RegexError {
+	set, err := parseClass(regString, parCtx, parCtx.loc()-1)
+	if err != nil {
+		return err
+	}
+	token := rgToken{
+		tokenType: bracket,
+		value:     set,
+	}
+	parCtx.push(token)
+	return nil
+}
 
-	if regString[parCtx.pos]=='^'{
-		tokenType= bracketNot
+// parse the content of a bracket up to its closing ']', where the position is left.
+// the class is the union of its pieces, which can be narrowed down with set operations:
+// '&&' keeps what's also in the pieces that follow, e.g., [a-z&&[^aeiou]], and '--' removes them,
+// e.g., [\w--\d]. a bracket can only be nested right after one of these, elsewhere '[' stands for itself
+func parseClass(regString string, parCtx *parsingContext, openPos int) (charset, *RegexError) {
+	negated := false
+	if parCtx.loc() < len(regString) && regString[parCtx.loc()] == '^' {
+		negated = true
 		parCtx.adv()
-	}else{
-		tokenType=bracket
-	}
-	//literals within []
-	var pieces[]string
-	for parCtx.loc()< len(regString) && regString[parCtx.loc()]!=']'{
-		ch:= regString[parCtx.loc()]
-		if ch=='-' && parCtx.loc()+1<len(regString){
-			nextChar:= regString[parCtx.loc()+1]
-			if(len(pieces)==0 || nextChar==']'){
-				pieces = append(pieces, fmt.Sprintf("%c",ch))
-			}else{
+	}
+
+	foldCase := parCtx.flags&CaseInsensitive != 0
+	var set charset
+	operator := byte(0) // '&' or '-' once an operator was met
+	// literals and ranges read since the last operator, a single literal is a range of one code point
+	var pieces []runeRange
+	// classes read since the last operator, e.g., \d or [:alpha:], their case is already folded
+	var classes charset
+	combine := func() {
+		operand := newCharset(pieces...)
+		if foldCase {
+			// folded before the negation, so that (?i)[^a] leaves out both 'a' and 'A'
+			operand = operand.fold()
+		}
+		operand = operand.union(classes)
+		switch operator {
+		case '&':
+			set = set.intersect(operand)
+		case '-':
+			set = set.subtract(operand)
+		default:
+			set = operand
+		}
+		pieces = nil
+		classes = nil
+	}
+
+	pieceStart := parCtx.loc() // where the last piece starts, for the errors
+	afterClass := false        // a class like \d can't start a range, the '-' after it stands for itself
+	// a ']' right after the '[' or '[^' stands for itself, as in RE2 and POSIX, so '[]a]' is ']' or 'a'
+	first := parCtx.loc()
+	for parCtx.loc() < len(regString) && (regString[parCtx.loc()] != ']' || parCtx.loc() == first) {
+		ch, size := runeAt(regString, parCtx.loc())
+		rest := regString[parCtx.loc():]
+		if (strings.HasPrefix(rest, "&&") || strings.HasPrefix(rest, "--")) && len(rest) > 2 && rest[2] != ']' {
+			combine()
+			operator = rest[0]
+			parCtx.advTo(parCtx.loc() + 2)
+			afterClass = false
+			if strings.HasPrefix(regString[parCtx.loc():], "[") && !strings.HasPrefix(regString[parCtx.loc():], "[:") {
+				nestedPos := parCtx.loc()
 				parCtx.adv()
-				piece :=pieces[len(pieces)-1]
-				if(len(piece)==1){
-					prevChar:=piece[0]
-					if(prevChar<nextChar){
-						pieces[len(pieces)-1]= fmt.Sprintf("%c%c",prevChar,nextChar)
-					}else{
-						return &RegexError{
-							Code: SyntaxError,
-							Message: fmt.Sprintf("'%c-%c' Range Not Acceptable", prevChar, nextChar),
-							Pos: parCtx.loc(),
-						}
+				nested, err := parseClass(regString, parCtx, nestedPos)
+				if err != nil {
+					return nil, err
+				}
+				classes = classes.union(nested)
+				parCtx.adv()
+				afterClass = true
+			}
+			continue
+		}
+
+		if ch == '-' && parCtx.loc()+1 < len(regString) {
+			nextChar, _ := runeAt(regString, parCtx.loc()+1)
+			// only a single character can start a range, otherwise the '-' stands for itself
+			if len(pieces) == 0 || nextChar == ']' || afterClass || pieces[len(pieces)-1].lo != pieces[len(pieces)-1].hi {
+				pieceStart = parCtx.loc()
+				pieces = append(pieces, runeRange{lo: ch, hi: ch})
+			} else {
+				// the end of the range can be an escape too, e.g., [\x00-\x1f]
+				hi, next, err := classChar(regString, parCtx.loc()+1, foldCase)
+				if err != nil {
+					return nil, err
+				}
+				prevChar := pieces[len(pieces)-1].lo
+				if prevChar > hi {
+					return nil, &RegexError{
+						Code:    SyntaxError,
+						Kind:    ErrBadRange,
+						Message: fmt.Sprintf("'%s' Range Not Acceptable", regString[pieceStart:next]),
+						Pos:     pieceStart,
+						End:     next,
 					}
-				}else{
-					pieces = append(pieces, fmt.Sprintf("%c",ch))
 				}
+				pieces[len(pieces)-1] = runeRange{lo: prevChar, hi: hi}
+				parCtx.advTo(next)
+				afterClass = false
+				continue
 			}
-		}else if ch=='\\' && parCtx.loc()+1<len(regString){
-			nextChar:= regString[parCtx.adv()]
-			pieces=append(pieces, fmt.Sprintf("%c",nextChar))
-		}else{
-			pieces=append(pieces, fmt.Sprintf("%c",ch))
+		} else if ch == '\\' && parCtx.loc()+1 < len(regString) {
+			pieceStart = parCtx.loc()
+			nextChar, nextSize := runeAt(regString, parCtx.adv())
+			size = nextSize
+			if class, isClass := perlClass(nextChar, foldCase); isClass {
+				classes = classes.union(class)
+				parCtx.advTo(parCtx.loc() + size)
+				afterClass = true
+				continue
+			}
+			if nextChar == 'p' || nextChar == 'P' {
+				class, last, err := parseUnicodeClass(regString, parCtx.loc(), foldCase)
+				if err != nil {
+					return nil, err
+				}
+				classes = classes.union(class)
+				parCtx.advTo(last + 1)
+				afterClass = true
+				continue
+			}
+			value, next, err := classChar(regString, pieceStart, foldCase)
+			if err != nil {
+				return nil, err
+			}
+			pieces = append(pieces, runeRange{lo: value, hi: value})
+			parCtx.advTo(next)
+			afterClass = false
+			continue
+		} else if ch == '[' && strings.HasPrefix(rest, "[:") && strings.Contains(rest[2:], ":]") {
+			// [:name:] or [:^name:], a '[' that doesn't start one stands for itself
+			pieceStart = parCtx.loc()
+			end := parCtx.loc() + 2 + strings.Index(rest[2:], ":]") + 2
+			name := regString[parCtx.loc()+2 : end-2]
+			class, found := posixClasses[strings.TrimPrefix(name, "^")]
+			if !found {
+				return nil, &RegexError{
+					Code:    SyntaxError,
+					Kind:    ErrBadClass,
+					Message: fmt.Sprintf("Unknown POSIX class '%s'", name),
+					Pos:     pieceStart,
+					End:     end,
+				}
+			}
+			classes = classes.union(caseClass(class, strings.HasPrefix(name, "^"), foldCase))
+			parCtx.advTo(end)
+			afterClass = true
+			continue
+		} else {
+			pieceStart = parCtx.loc()
+			pieces = append(pieces, runeRange{lo: ch, hi: ch})
 		}
-		parCtx.adv()
+		parCtx.advTo(parCtx.loc() + size)
+		afterClass = false
 	}
-	uniqueCharPieces:=map[uint8]bool{}
-	for _,piece :=range pieces{
-		for s:= piece[0];s<=piece[len(piece)-1];s++{
-			uniqueCharPieces[s]=true
+	if parCtx.loc() >= len(regString) {
+		return nil, &RegexError{
+			Code:    SyntaxError,
+			Kind:    ErrUnbalancedBracket,
+			Message: "Bracket has not been properly closed",
+			Pos:     openPos,
+			End:     len(regString),
 		}
 	}
-	token:=rgToken{
-		tokenType: tokenType,
-		value: uniqueCharPieces,
-	}
-	parCtx.tokens=append(parCtx.tokens, token)
 
-	return nil
+	combine()
+	if negated {
+		set = set.negate()
+	}
+	return set, nil
 }
 //////////////////////////////////////////////
 
 //parse groups ()
 func parseGroup(regString string,parCtx *parsingContext) *RegexError{
-	groupContext:=parsingContext{
-		pos: parCtx.loc(),
-		tokens: []rgToken{},
-	}
+	groupContext:=parCtx.nested()
+	openPos:=groupContext.loc()-1
 	groupName:=""
-	if regString[groupContext.loc()]=='?'{
-		if regString[groupContext.adv()]=='<'{
-			for regString[groupContext.adv()]!='>'{
-				ch:=regString[groupContext.loc()]
-				groupName+=fmt.Sprintf("%c",ch)
+	if groupContext.loc()<len(regString) && regString[groupContext.loc()]=='?'{
+		groupContext.adv()
+		if strings.HasPrefix(regString[groupContext.loc():], "=") || strings.HasPrefix(regString[groupContext.loc():], "!"){
+			return parseLookaround(regString,parCtx,openPos,groupContext.loc())
+		}
+		if strings.HasPrefix(regString[groupContext.loc():], "<=") || strings.HasPrefix(regString[groupContext.loc():], "<!"){
+			return parseLookaround(regString,parCtx,openPos,groupContext.loc()+1)
+		}
+		if groupContext.loc()<len(regString) && regString[groupContext.loc()]=='<'{
+			nameStart:=groupContext.adv()
+			for groupContext.loc()<len(regString) && regString[groupContext.loc()]!='>'{
+				groupContext.adv()
 			}
-		}else{
-			return &RegexError{
-				Code: SyntaxError,
-				Message: "group name invalid",
-				Pos: groupContext.loc(),
+			if groupContext.loc()>=len(regString){
+				return &RegexError{
+					Code: SyntaxError,
+					Kind: ErrBadGroupName,
+					Message: "group name has not been properly closed",
+					Pos: openPos,
+					End: len(regString),
+				}
+			}
+			groupName=regString[nameStart:groupContext.loc()]
+			if groupName==""{
+				return &RegexError{
+					Code: SyntaxError,
+					Kind: ErrBadGroupName,
+					Message: "group name is empty",
+					Pos: openPos,
+					End: groupContext.loc()+1,
+				}
+			}
+		}else if groupContext.loc()<len(regString) && regString[groupContext.loc()]=='>'{
+			tokens, err:=parseGroupContent(regString,parCtx,openPos,groupContext.loc()+1,parCtx.flags)
+			if err!=nil{
+				return err
 			}
+			parCtx.push(rgToken{
+				tokenType: groupAtomic,
+				value: tokens,
+			})
+			return nil
+		}else{
+			return parseFlags(regString,parCtx,openPos)
 		}
 		groupContext.adv()
 	}
+	groupIndex:=groupContext.nextGroup(groupName)
 
 	for groupContext.loc()<len(regString) && regString[groupContext.loc()]!=')'{
-		ch:=regString[groupContext.loc()]
+		ch,_:=runeAt(regString,groupContext.loc())
 		if err:=processChar(regString,&groupContext,ch); err!=nil{
 			return err
 		}
 		groupContext.adv()
 	}
-	if regString[groupContext.loc()] != ')' {
+	if groupContext.loc() >= len(regString) {
 		return &RegexError{
 			Code:    SyntaxError,
+			Kind:    ErrUnbalancedParen,
 			Message: "Group has not been properly closed",
-			Pos:     groupContext.loc(),
+			Pos:     openPos,
+			End:     openPos + 1,
 		}
 	}
 
@@ -259,6 +481,7 @@ func parseGroup(regString string,parCtx *parsingContext) *RegexError{
 		value: groupPayload{
 			token: groupContext.tokens,
 			name:   groupName,
+			index:  groupIndex,
 		},
 	}
 	parCtx.push(token)
@@ -267,236 +490,754 @@ func parseGroup(regString string,parCtx *parsingContext) *RegexError{
 }
 /////////////////////////////////////
 //parse quantifiers
-func parseQuant(ch uint8,parCtx *parsingContext){
+// letters allowed in '(?flags)'
+var inlineFlags = map[byte]Options{
+	'i': CaseInsensitive,
+	'm': Multiline,
+	's': DotAll,
+	'U': Ungreedy,
+}
+
+// parse '(?flags)', which changes the flags for the rest of the enclosing group,
+// or '(?flags:...)', which only changes them inside, e.g., (?i)abc or (?i-s:a.c).
+// the flags after a '-' are cleared
+func parseFlags(regString string,parCtx *parsingContext,openPos int) *RegexError{
+	flags:=parCtx.flags
+	clearing:=false
+	pos:=openPos+2
+	for ;pos<len(regString) && regString[pos]!=')' && regString[pos]!=':';pos++{
+		flag, known:=inlineFlags[regString[pos]]
+		if regString[pos]=='-' && !clearing{
+			clearing=true
+			continue
+		}
+		if !known{
+			return &RegexError{
+				Code: SyntaxError,
+				Kind: ErrBadFlags,
+				Message: fmt.Sprintf("Unknown flag '%c'", regString[pos]),
+				Pos: openPos,
+				End: pos+1,
+			}
+		}
+		if clearing{
+			flags&^=flag
+		}else{
+			flags|=flag
+		}
+	}
+	if pos>=len(regString){
+		return &RegexError{
+			Code: SyntaxError,
+			Kind: ErrUnbalancedParen,
+			Message: "Group has not been properly closed",
+			Pos: openPos,
+			End: openPos+1,
+		}
+	}
+	if regString[pos-1]=='-' || (regString[pos]==')' && pos==openPos+2){
+		return &RegexError{
+			Code: SyntaxError,
+			Kind: ErrBadFlags,
+			Message: "Missing flags",
+			Pos: openPos,
+			End: pos+1,
+		}
+	}
+	if regString[pos]==')'{
+		parCtx.flags=flags
+		parCtx.advTo(pos)
+		return nil
+	}
+	tokens, err:=parseGroupContent(regString,parCtx,openPos,pos+1,flags)
+	if err!=nil{
+		return err
+	}
+	parCtx.push(rgToken{
+		tokenType: groupUncaptured,
+		value: tokens,
+	})
+	return nil
+}
+
+// parse what's inside of a group that doesn't capture, from 'contentStart' up to the ')'
+// where the position is left, e.g., the content of (?:...), (?i:...) or (?>...)
+func parseGroupContent(regString string,parCtx *parsingContext,openPos int,contentStart int,flags Options) ([]rgToken, *RegexError){
+	groupContext:=parCtx.nested()
+	groupContext.flags=flags
+	groupContext.advTo(contentStart)
+	for groupContext.loc()<len(regString) && regString[groupContext.loc()]!=')'{
+		ch,_:=runeAt(regString,groupContext.loc())
+		if err:=processChar(regString,&groupContext,ch); err!=nil{
+			return nil, err
+		}
+		groupContext.adv()
+	}
+	if groupContext.loc() >= len(regString) {
+		return nil, &RegexError{
+			Code: SyntaxError,
+			Kind: ErrUnbalancedParen,
+			Message: "Group has not been properly closed",
+			Pos: openPos,
+			End: openPos+1,
+		}
+	}
+	parCtx.advTo(groupContext.loc())
+	return groupContext.tokens, nil
+}
+
+// parse (?=...), (?!...), (?<=...) or (?<!...), 'pos' being on the '=' or the '!'.
+// a lookbehind has to have a bounded length, the matcher tries each length it can have
+func parseLookaround(regString string,parCtx *parsingContext,openPos int,pos int) *RegexError{
+	tokens, err:=parseGroupContent(regString,parCtx,openPos,pos+1,parCtx.flags)
+	if err!=nil{
+		return err
+	}
+	payload:=lookaroundPayload{
+		token: tokens,
+		negated: regString[pos]=='!',
+	}
+	tokenType:=rgTokenType(lookahead)
+	bounded:=false
+	payload.minLen, payload.maxLen, bounded=tokensLength(tokens)
+	if !bounded{
+		// fine for a lookahead, it only keeps the streaming matchers from reading the input piece by piece
+		payload.maxLen=quantInfinity
+	}
+	if regString[pos-1]=='<'{
+		tokenType=lookbehind
+		if !bounded{
+			return &RegexError{
+				Code: SyntaxError,
+				Kind: ErrUnboundedLookbehind,
+				Message: "Lookbehind has to have a bounded length",
+				Pos: openPos,
+				End: parCtx.loc()+1,
+			}
+		}
+	}
+	parCtx.push(rgToken{
+		tokenType: tokenType,
+		value: payload,
+	})
+	return nil
+}
+
+// the least and the most characters the tokens can match, bounded is false
+// when there's no most, e.g., with a '*' or a backreference
+func tokensLength(tokens []rgToken) (minLen int, maxLen int, bounded bool){
+	for _, token:=range tokens{
+		lo, hi, ok:=tokenLength(token)
+		if !ok{
+			return 0, 0, false
+		}
+		minLen+=lo
+		maxLen+=hi
+	}
+	return minLen, maxLen, true
+}
+func tokenLength(token rgToken) (minLen int, maxLen int, bounded bool){
+	switch token.tokenType{
+	case literal, bracket, wildcard:
+		return 1, 1, true
+	case textBeginning, textEnd, wordBoundary, lookahead, lookbehind:
+		return 0, 0, true
+	case groupCaptured:
+		return tokensLength(token.value.(groupPayload).token)
+	case groupUncaptured, groupAtomic:
+		return tokensLength(token.value.([]rgToken))
+	case or:
+		for i, branch:=range token.value.([]rgToken){
+			lo, hi, ok:=tokenLength(branch)
+			if !ok{
+				return 0, 0, false
+			}
+			if i==0 || lo<minLen{
+				minLen=lo
+			}
+			maxLen=max(maxLen, hi)
+		}
+		return minLen, maxLen, true
+	case quantifier:
+		payload:=token.value.(quantPayload)
+		lo, hi, ok:=tokenLength(payload.value)
+		if !ok || payload.max==quantInfinity{
+			return 0, 0, false
+		}
+		return lo*payload.min, hi*payload.max, true
+	}
+	return 0, 0, false
+}
+
+func parseQuant(regString string,ch rune,parCtx *parsingContext) *RegexError{
+	if len(parCtx.tokens)==0{
+		return &RegexError{
+			Code: SyntaxError,
+			Kind: ErrMissingRepeatArgument,
+			Message: fmt.Sprintf("Nothing to repeat with '%c'", ch),
+			Pos: parCtx.loc(),
+			End: parCtx.loc()+1,
+		}
+	}
+	if err:=nestedRepetition(parCtx,string(ch),parCtx.loc(),parCtx.loc()+1);err!=nil{
+		return err
+	}
 	bound :=quantToCurly[ch]
+	payload :=quantPayload{
+		min: bound[0],
+		max: bound[1],
+		value: parCtx.remLast(1)[0],
+		lazy: parCtx.flags&Ungreedy!=0,
+	}
+	parseQuantSuffix(regString,parCtx,&payload)
 	token :=rgToken{
 		tokenType: quantifier,
-		value: quantPayload{
-			min: bound[0],
-			max: bound[1],
-			value: parCtx.remLast(1)[0],
-		},
+		value: payload,
 	}
 	parCtx.push(token)
+	return nil
+}
+// a quantifier can't follow another one, e.g., a** or a{2}*, only the '?' and '+' suffixes
+// can and they're read along with it. a group around it, as in (?:a*)*, is fine
+func nestedRepetition(parCtx *parsingContext, op string, pos int, end int) *RegexError {
+	if len(parCtx.tokens) == 0 || parCtx.tokens[len(parCtx.tokens)-1].tokenType != quantifier {
+		return nil
+	}
+	return &RegexError{
+		Code:    SyntaxError,
+		Kind:    ErrBadRepetition,
+		Message: fmt.Sprintf("Nested repetition operator '%s', what it follows is already repeated", op),
+		Pos:     pos,
+		End:     end,
+	}
+}
+// a '?' right after a quantifier makes it lazy (greedy under the ungreedy flag)
+// and a '+' makes it possessive, e.g., a*? or a{2,}+
+func parseQuantSuffix(regString string,parCtx *parsingContext,payload *quantPayload){
+	if parCtx.loc()+1>=len(regString){
+		return
+	}
+	switch regString[parCtx.loc()+1]{
+	case '?':
+		payload.lazy=!payload.lazy
+		parCtx.adv()
+	case '+':
+		payload.possessive=true
+		parCtx.adv()
+	}
 }
 ////////////////////////////////////
 //parse quants {}
 func parseBounded(rgString string,parCtx *parsingContext) *RegexError{
+	openPos:=parCtx.loc()
+	if len(parCtx.tokens)==0{
+		return &RegexError{
+			Code: SyntaxError,
+			Kind: ErrMissingRepeatArgument,
+			Message: "Nothing to repeat with '{'",
+			Pos: openPos,
+			End: openPos+1,
+		}
+	}
 	starPos:=parCtx.adv()
 	endPos:=parCtx.loc()
-	for rgString[endPos]!='}'{
+	for endPos<len(rgString) && rgString[endPos]!='}'{
 		endPos++
 	}
+	if endPos>=len(rgString){
+		return &RegexError{
+			Code: SyntaxError,
+			Kind: ErrBadRepetition,
+			Message: "Bounded quantifier has not been properly closed",
+			Pos: openPos,
+			End: len(rgString),
+		}
+	}
 	parCtx.advTo(endPos)
 	rang :=rgString[starPos:endPos]
 	pieces :=strings.Split(rang,",")
 	if len(pieces)==0{
 		return &RegexError{
 			Code: SyntaxError,
+			Kind: ErrBadRepetition,
 			Message: "Atleast one bound required",
-			Pos: starPos,
+			Pos: openPos,
+			End: endPos+1,
 		}
 		
 	}
+	badBound:=func(bound string) *RegexError{
+		message:=fmt.Sprintf("Invalid bound '%s' in '{%s}'", bound, rang)
+		if bound==""{
+			message=fmt.Sprintf("Missing lower bound in '{%s}'", rang)
+		}
+		return &RegexError{
+			Code: SyntaxError,
+			Kind: ErrBadRepetition,
+			Message: message,
+			Pos: openPos,
+			End: endPos+1,
+		}
+	}
 	var start int
 	var end int
 	var err error
 	if len(pieces)==1{
 		start,err = strconv.Atoi(pieces[0])
 		if err!=nil{
-			return &RegexError{
-				Code: SyntaxError,
-				Message: err.Error(),
-				Pos: starPos,
-			}
+			return badBound(pieces[0])
 		}
 		end=start
 	}else if len(pieces)==2{
 		start,err = strconv.Atoi(pieces[0])
 		if err!=nil{
-			return &RegexError{
-				Code: SyntaxError,
-				Message: err.Error(),
-				Pos: starPos,
-			}
+			return badBound(pieces[0])
 		}
 		if(pieces[1]==""){
 			end = quantInfinity
 		}else{
 			end,err = strconv.Atoi(pieces[1])
 			if err!=nil{
-				return &RegexError{
-					Code: SyntaxError,
-					Message: err.Error(),
-					Pos: starPos,
-				}
+				return badBound(pieces[1])
 			}
 		}
+	}else{
+		return &RegexError{
+			Code: SyntaxError,
+			Kind: ErrBadRepetition,
+			Message: "Atmost two bounds allowed",
+			Pos: openPos,
+			End: endPos+1,
+		}
+	}
+	if start<0 || (end!=quantInfinity && (end<0 || end<start)){
+		return &RegexError{
+			Code: SyntaxError,
+			Kind: ErrBadRepetition,
+			Message: fmt.Sprintf("'{%s}' Range Not Acceptable", rang),
+			Pos: openPos,
+			End: endPos+1,
+		}
+	}
+	if err:=nestedRepetition(parCtx,rgString[openPos:endPos+1],openPos,endPos+1);err!=nil{
+		return err
 	}
+	if start>maxRepeat || end>maxRepeat{
+		return &RegexError{
+			Code: SyntaxError,
+			Kind: ErrRepetitionTooLarge,
+			Message: fmt.Sprintf("'{%s}' repeats more than %d times", rang, maxRepeat),
+			Pos: openPos,
+			End: endPos+1,
+		}
+	}
+	payload :=quantPayload{
+		min: start,
+		max: end,
+		value: parCtx.remLast(1)[0],
+		lazy: parCtx.flags&Ungreedy!=0,
+	}
+	parseQuantSuffix(rgString,parCtx,&payload)
 	token :=rgToken{
 		tokenType: quantifier,
-		value: quantPayload{
-			min: start,
-			max: end,
-			value: parCtx.remLast(1)[0],
-		},
+		value: payload,
 	}
 	parCtx.push(token)
 	return nil
 }
+////////////////////////////////////////////
+//parse hex escapes, \xHH or \x{HHHH}, 'pos' is on the 'x'
+//returns the code point and the position of the last byte of the escape.
+//\x80 to \xff are the code points U+0080 to U+00FF, they only match the raw bytes 0x80 to 0xff
+//with the Latin1 option, where each byte is read as the code point of the same value
+func parseHexEscape(regString string, pos int) (rune, int, *RegexError) {
+	digitsStart := pos + 1
+	digitsEnd := pos + 3
+	if digitsStart < len(regString) && regString[digitsStart] == '{' {
+		digitsStart++
+		closing := strings.IndexByte(regString[digitsStart:], '}')
+		if closing == -1 {
+			return 0, pos, &RegexError{
+				Code:    SyntaxError,
+				Kind:    ErrBadEscape,
+				Message: "Hex escape has not been properly closed",
+				Pos:     pos - 1,
+				End:     len(regString),
+			}
+		}
+		digitsEnd = digitsStart + closing
+	}
+	if digitsEnd > len(regString) || digitsStart == digitsEnd {
+		return 0, pos, &RegexError{
+			Code:    SyntaxError,
+			Kind:    ErrBadEscape,
+			Message: "Invalid hex escape",
+			Pos:     pos - 1,
+			End:     min(digitsEnd, len(regString)),
+		}
+	}
+	value, err := strconv.ParseUint(regString[digitsStart:digitsEnd], 16, 32)
+	if err != nil || value > unicode.MaxRune {
+		return 0, pos, &RegexError{
+			Code:    SyntaxError,
+			Kind:    ErrBadEscape,
+			Message: fmt.Sprintf("Invalid hex escape '%s'", regString[pos-1:digitsEnd]),
+			Pos:     pos - 1,
+			End:     digitsEnd,
+		}
+	}
+	last := digitsEnd - 1
+	if regString[pos+1] == '{' {
+		last = digitsEnd
+	}
+	return rune(value), last, nil
+}
+// read the single character at 'pos' within a bracket, either as is or as an escape like
+// \x41, \n or \], and return it along with the offset right after it. a class like \d
+// stands for more than one character and is rejected, it's only asked for where a range ends
+func classChar(regString string, pos int, foldCase bool) (rune, int, *RegexError) {
+	ch, size := runeAt(regString, pos)
+	if ch != '\\' || pos+1 >= len(regString) {
+		return ch, pos + size, nil
+	}
+	escaped, escapedSize := runeAt(regString, pos+1)
+	if _, isClass := perlClass(escaped, foldCase); isClass || escaped == 'p' || escaped == 'P' {
+		return 0, pos, &RegexError{
+			Code:    SyntaxError,
+			Kind:    ErrBadRange,
+			Message: fmt.Sprintf("'\\%c' can't end a range, it's a class", escaped),
+			Pos:     pos,
+			End:     pos + 1 + escapedSize,
+		}
+	}
+	if escaped == 'x' {
+		value, last, err := parseHexEscape(regString, pos+1)
+		if err != nil {
+			return 0, pos, err
+		}
+		return value, last + 1, nil
+	}
+	if control, ok := controlEscapes[escaped]; ok {
+		escaped = control
+	}
+	return escaped, pos + 1 + escapedSize, nil
+}
+
 ////////////////////////////////////////////
 //parse backslash
 
+var controlEscapes = map[rune]rune{
+	'n': '\n',
+	't': '\t',
+	'r': '\r',
+	'f': '\f',
+	'v': '\v',
+	'a': '\a',
+}
+
+// parse a unicode class, 'pos' being at the 'p' of \pL, \p{Greek} or \p{^Greek}, a 'P' negates it.
+// returns the ranges and the position of the last byte of the class
+func parseUnicodeClass(regString string, pos int, foldCase bool) (charset, int, *RegexError) {
+	negated := regString[pos] == 'P'
+	name, last := "", pos+1
+	if last < len(regString) && regString[last] == '{' {
+		closing := strings.IndexByte(regString[last:], '}')
+		if closing == -1 {
+			return nil, pos, &RegexError{
+				Code:    SyntaxError,
+				Kind:    ErrBadClass,
+				Message: "Unicode class has not been properly closed",
+				Pos:     pos - 1,
+				End:     len(regString),
+			}
+		}
+		name = regString[last+1 : last+closing]
+		last += closing
+	} else if last < len(regString) {
+		ch, size := runeAt(regString, last)
+		name = string(ch)
+		last += size - 1
+	}
+	if strings.HasPrefix(name, "^") {
+		negated = !negated
+		name = name[1:]
+	}
+
+	ranges, found := unicodeClass(name)
+	if !found {
+		return nil, pos, &RegexError{
+			Code:    SyntaxError,
+			Kind:    ErrBadClass,
+			Message: fmt.Sprintf("Unknown unicode class '%s'", name),
+			Pos:     pos - 1,
+			End:     min(last+1, len(regString)),
+		}
+	}
+	return caseClass(ranges, negated, foldCase), last, nil
+}
+
+// text boundaries that don't change with the multiline flag
+var anchorEscapes = map[rune]rgToken{
+	'A': {tokenType: textBeginning, value: assertTextStart},
+	'z': {tokenType: textEnd, value: assertTextEnd},
+	'Z': {tokenType: textEnd, value: assertTextEndNewline},
+}
+
+// a backreference can only point to a group opened before it
+func unknownGroupError(groupName string, pos int, end int) *RegexError {
+	return &RegexError{
+		Code:    SyntaxError,
+		Kind:    ErrUnknownGroup,
+		Message: fmt.Sprintf("Group (%s) does not exist", groupName),
+		Pos:     pos,
+		End:     end,
+	}
+}
+
 func parseBackslash(regString string,parCtx *parsingContext) * RegexError{
-	nextChar := regString[parCtx.loc()+1]
+	if parCtx.loc()+1 >= len(regString) {
+		return &RegexError{
+			Code:    SyntaxError,
+			Kind:    ErrTrailingBackslash,
+			Message: "Pattern ends with an unfinished escape",
+			Pos:     parCtx.loc(),
+			End:     parCtx.loc() + 1,
+		}
+	}
+	nextChar, size := runeAt(regString, parCtx.loc()+1)
 	if isDig(nextChar) { // cares about the next single digit
+		groupName := fmt.Sprintf("%c", nextChar)
+		if !parCtx.hasGroup(groupName) {
+			return unknownGroupError(groupName, parCtx.loc(), parCtx.loc()+2)
+		}
 		token := rgToken{
 			tokenType: backReference,
-			value:     fmt.Sprintf("%c", nextChar),
+			value: backrefPayload{
+				name:     groupName,
+				foldCase: parCtx.flags&CaseInsensitive != 0,
+			},
 		}
 		parCtx.push(token)
 		parCtx.adv()
 	} else if nextChar == 'k' { // \k<name> reference
+		escapePos := parCtx.loc()
 		parCtx.adv()
-		if regString[parCtx.adv()] == '<' {
-			groupName := ""
-			for regString[parCtx.adv()] != '>' {
-				nextChar = regString[parCtx.loc()]
-				groupName += fmt.Sprintf("%c", nextChar)
+		if parCtx.adv() < len(regString) && regString[parCtx.loc()] == '<' {
+			nameStart := parCtx.adv()
+			for parCtx.loc() < len(regString) && regString[parCtx.loc()] != '>' {
+				parCtx.adv()
+			}
+			if parCtx.loc() >= len(regString) || parCtx.loc() == nameStart {
+				return &RegexError{
+					Code:    SyntaxError,
+					Kind:    ErrBadBackreference,
+					Message: "Invalid backreference syntax",
+					Pos:     escapePos,
+					End:     min(parCtx.loc()+1, len(regString)),
+				}
+			}
+			groupName := regString[nameStart:parCtx.loc()]
+			if !parCtx.hasGroup(groupName) {
+				return unknownGroupError(groupName, escapePos, parCtx.loc()+1)
 			}
 			token := rgToken{
 				tokenType: backReference,
-				value:     groupName,
+				value: backrefPayload{
+					name:     groupName,
+					foldCase: parCtx.flags&CaseInsensitive != 0,
+				},
 			}
 			parCtx.push(token)
-			parCtx.adv()
 		} else {
 			return &RegexError{
 				Code:    SyntaxError,
+				Kind:    ErrBadBackreference,
 				Message: "Invalid backreference syntax",
-				Pos:     parCtx.loc(),
+				Pos:     escapePos,
+				End:     min(parCtx.loc()+1, len(regString)),
 			}
 		}
-	} else if _, canBeEscaped := mustBeEscapedChar[nextChar]; canBeEscaped {
+	} else if nextChar == 'x' { // \xHH or \x{HHHH} code point
+		value, last, err := parseHexEscape(regString, parCtx.loc()+1)
+		if err != nil {
+			return err
+		}
+		parCtx.pushLiteral(value)
+		parCtx.advTo(last)
+	} else if ranges, isClass := perlClass(nextChar, parCtx.flags&CaseInsensitive != 0); isClass { // \d, \w, \s and their complements
 		token := rgToken{
-			tokenType: literal,
-			value:     nextChar,
+			tokenType: bracket,
+			value:     ranges,
 		}
 		parCtx.push(token)
 		parCtx.adv()
-	} else {
-		if nextChar == 'n' {
-			nextChar = '\n'
-		} else if nextChar == 't' {
-			nextChar = '\t'
+	} else if nextChar == 'p' || nextChar == 'P' { // \pL, \p{Greek} and their complements
+		ranges, last, err := parseUnicodeClass(regString, parCtx.loc()+1, parCtx.flags&CaseInsensitive != 0)
+		if err != nil {
+			return err
 		}
 		token := rgToken{
-			tokenType: literal,
-			value:     nextChar,
+			tokenType: bracket,
+			value:     ranges,
+		}
+		parCtx.push(token)
+		parCtx.advTo(last)
+	} else if nextChar == 'b' || nextChar == 'B' { // word boundary or not
+		anchor := assertWordBoundary
+		if nextChar == 'B' {
+			anchor = assertNotWordBoundary
+		}
+		if parCtx.flags&UnicodeWord != 0 {
+			anchor |= assertUnicodeWord
+		}
+		token := rgToken{
+			tokenType: wordBoundary,
+			value:     anchor,
 		}
 		parCtx.push(token)
 		parCtx.adv()
+	} else if token, isAnchor := anchorEscapes[nextChar]; isAnchor {
+		parCtx.push(token)
+		parCtx.adv()
+	} else if _, canBeEscaped := mustBeEscapedChar[nextChar]; canBeEscaped {
+		parCtx.pushLiteral(nextChar)
+		parCtx.adv()
+	} else {
+		if control, ok := controlEscapes[nextChar]; ok {
+			nextChar = control
+		} else if nextChar < utf8.RuneSelf && unicode.IsLetter(nextChar) {
+			// letters are kept for the escapes to come instead of meaning themselves
+			return &RegexError{
+				Code:    SyntaxError,
+				Kind:    ErrUnknownEscape,
+				Message: fmt.Sprintf("Unknown escape '\\%c'", nextChar),
+				Pos:     parCtx.loc(),
+				End:     parCtx.loc() + 1 + size,
+			}
+		}
+		parCtx.pushLiteral(nextChar)
+		parCtx.advTo(parCtx.loc() + size)
 	}
 
 	return nil
 }
 /////////////////////////////////////////////
-//parse literal
-func parseLiteral(ch uint8,parCtx *parsingContext){
-	token := rgToken{
-		tokenType: literal,
-		value: ch,
-	}
-	parCtx.push(token)
+//parse literal, leaves the position on the last byte of the code point
+func parseLiteral(regString string,parCtx *parsingContext){
+	ch, size := runeAt(regString, parCtx.loc())
+	parCtx.pushLiteral(ch)
+	parCtx.advTo(parCtx.loc()+size-1)
 }
 /////////////////////////////////////////////
-//parse group uncaptured
-func parseGroupUncaptured(regString string,parCtx *parsingContext)* RegexError{
-	groupCtx := parsingContext{
-		pos: parCtx.loc(),
-		tokens: []rgToken{},
-	}
-	for groupCtx.loc()<len(regString) && regString[groupCtx.loc()]!=')'{
-		ch:=regString[groupCtx.loc()]
-		if err:=processChar(regString,&groupCtx,ch);err!=nil{
-			return err
+//parse alternation, the position being on the first '|'. everything before it in the
+//enclosing group is the first branch, the other branches go up to the next '|', the
+//group's ')' or the end. the position is left on the last byte of the last branch
+func parseAlternation(regString string,parCtx *parsingContext) *RegexError{
+	branches:=[]rgToken{{
+		tokenType: groupUncaptured,
+		value: parCtx.remLast(len(parCtx.tokens)),
+	}}
+	for parCtx.loc()<len(regString) && regString[parCtx.loc()]=='|'{
+		branchCtx:=parCtx.nested()
+		branchCtx.adv()
+		for branchCtx.loc()<len(regString) && regString[branchCtx.loc()]!=')' && regString[branchCtx.loc()]!='|'{
+			ch,_:=runeAt(regString,branchCtx.loc())
+			if err:=processChar(regString,&branchCtx,ch);err!=nil{
+				return err
+			}
+			branchCtx.adv()
 		}
-		groupCtx.adv()
+		branches=append(branches, rgToken{
+			tokenType: groupUncaptured,
+			value: branchCtx.tokens,
+		})
+		// flags set in a branch carry on to the next ones, up to the end of the group
+		parCtx.flags=branchCtx.flags
+		parCtx.advTo(branchCtx.loc())
 	}
 	token :=rgToken{
-		tokenType: groupUncaptured,
-		value: groupCtx.tokens,
+		tokenType: or,
+		value: branches,
 	}
 	parCtx.push(token)
-
-	if groupCtx.loc() >= len(regString){
-		parCtx.advTo(groupCtx.loc())
-	}else if regString[groupCtx.loc()]==')'{
-		parCtx.advTo(groupCtx.loc()-1)
-	}
+	parCtx.advTo(parCtx.loc()-1)
 	return nil
 }
 // process all incoming char
-func processChar(regString string,parCtx *parsingContext,ch uint8) *RegexError{
+func processChar(regString string,parCtx *parsingContext,ch rune) *RegexError{
 	if ch=='('{
 		parCtx.adv()
 		if err:=parseGroup(regString,parCtx); err!=nil{
-			return nil
+			return err
 		}
 	}else if ch=='['{
 		parCtx.adv()
 		if err:=parseBracket(regString,parCtx);err!=nil{
-			return nil
+			return err
 		}
 	}else if isQuantifier(ch){
-		parseQuant(ch,parCtx)
+		if err:=parseQuant(regString,ch,parCtx);err!=nil{
+			return err
+		}
 	}else if ch=='{'{
 		if err:=parseBounded(regString,parCtx);err!=nil{
-			return nil
+			return err
 		}
 	}else if ch=='\\'{
 		if err:=parseBackslash(regString,parCtx);err!=nil{
-			return nil
+			return err
 		}
 	}else if isWild(ch){
 		token:=rgToken{
 			tokenType: wildcard,
 			value: ch,
 		}
+		if parCtx.flags&DotAll!=0{
+			// every code point, the newline included
+			token=rgToken{
+				tokenType: bracket,
+				value: charset{{lo: 0, hi: unicode.MaxRune}},
+			}
+		}
 		parCtx.push(token)
+	}else if ch==')'{
+		// the groups stop right before their ')', so this one closes nothing
+		return &RegexError{
+			Code: SyntaxError,
+			Kind: ErrUnbalancedParen,
+			Message: "Unmatched ')'",
+			Pos: parCtx.loc(),
+			End: parCtx.loc()+1,
+		}
 	}else if isLiteral(ch){
-		parseLiteral(ch,parCtx)
+		parseLiteral(regString,parCtx)
 	}else if ch=='|'{
-		//left side of OR
-		left:=rgToken{
-			tokenType: groupUncaptured,
-			value: parCtx.remLast(len(parCtx.tokens)),
-		}
-		//OR itself
-		parCtx.adv()
-		if err:= parseGroupUncaptured(regString,parCtx); err!=nil{
+		if err:=parseAlternation(regString,parCtx); err!=nil{
 			return err
 		}
-		//right side of OR
-		right:=parCtx.remLast(1)[0]
-
-		token :=rgToken{
-			tokenType: or,
-			value: []rgToken{left,right},
-		}
-		parCtx.push(token)
 	}else if(ch=='^'){
+		anchor:=assertTextStart
+		if parCtx.flags&Multiline!=0{
+			anchor=assertLineStart
+		}
 		token :=rgToken{
 			tokenType: rgTokenType(textBeginning),
-			value: ch,
+			value: anchor,
 		}
 		parCtx.push(token)
-	}else if(ch=='&'){
+	}else if(ch=='$'){
+		anchor:=assertTextEnd
+		if parCtx.flags&Multiline!=0{
+			anchor=assertLineEnd
+		}
 		token :=rgToken{
 			tokenType: rgTokenType(textEnd),
-			value: ch,
+			value: anchor,
 		}
 		parCtx.push(token)
+	}else{
+		// anything else stands for itself, e.g., '@', '#' or '<'
+		parseLiteral(regString,parCtx)
 	}
 	return nil
 }
@@ -504,7 +1245,7 @@ func processChar(regString string,parCtx *parsingContext,ch uint8) *RegexError{
 // parsing the content for finding the regex string
 func parse(regString string, parCtx *parsingContext) *RegexError {
 	for parCtx.loc() < len(regString) {
-		ch := regString[parCtx.loc()]
+		ch, _ := runeAt(regString, parCtx.loc())
 		if err := processChar(regString, parCtx, ch); err != nil {
 			return err
 		}
@@ -0,0 +1,139 @@
+package goregex
+
+import "unicode/utf8"
+
+// a thread of the pike vm, a state waiting for the next character
+// along with the captures made on the way there
+type thread struct {
+	state *State
+	caps  []int
+}
+
+// threads ordered by their priority, a state can only be in the list once per position
+type threadList[T inputText] struct {
+	threads []thread
+	mark    []int // generation in which each state was last added
+	gen     int
+	offset  int // byte offset of the text 'add' gets within the whole input, captures are recorded from there
+	looks   *lookaroundCache
+}
+
+func newThreadList[T inputText](size int) *threadList[T] {
+	return &threadList[T]{
+		mark: make([]int, size),
+		gen:  1,
+	}
+}
+
+func (l *threadList[T]) clear() {
+	l.threads = l.threads[:0]
+	l.gen++
+}
+
+// follow the epsilon edges of 's' at the byte offset 'pos' and queue every state
+// that either waits for a character or is terminal, keeping the priority order
+func (l *threadList[T]) add(s *State, input T, pos int, caps []int) {
+	if l.mark[s.id] == l.gen {
+		// a thread with a higher priority already got here
+		return
+	}
+	l.mark[s.id] = l.gen
+
+	if s.capturesInto(len(caps)) {
+		// captures are shared between threads, copy before writing
+		caps = append([]int(nil), caps...)
+		for _, capturedGroup := range s.groups {
+			if 2*capturedGroup.index >= len(caps) {
+				continue
+			}
+			if capturedGroup.start {
+				caps[2*capturedGroup.index] = l.offset + pos
+				caps[2*capturedGroup.index+1] = -1
+			}
+			if capturedGroup.end {
+				caps[2*capturedGroup.index+1] = l.offset + pos
+			}
+		}
+	}
+
+	if !assertionsHold(s, input, pos) || (s.lookaround != nil && !lookaroundHoldsAt(l.looks, s.lookaround, input, pos)) {
+		return
+	}
+
+	if s.terminal {
+		l.threads = append(l.threads, thread{state: s, caps: caps})
+		return
+	}
+
+	for _, state := range s.epsilon {
+		l.add(state, input, pos, caps)
+	}
+
+	if len(s.transitions) > 0 || len(s.classes) > 0 || s.wildcard != nil {
+		l.threads = append(l.threads, thread{state: s, caps: caps})
+	}
+}
+
+// look for the leftmost match starting at or after the byte offset 'from' by
+// simulating every path of the NFA at once, returns the capture slots.
+// each character is looked at once by at most one thread per state,
+// giving O(n*m) time and O(m) live threads; backreferences are not supported.
+// the input is read as the threads move on, the window keeps what the lookarounds
+// can reach and, if asked to, the text of the threads still alive
+func pikeRun[T inputText](p *program, w *inputWindow[T], from int) []int {
+	current := newThreadList[T](len(p.states))
+	next := newThreadList[T](len(p.states))
+	current.looks, next.looks = w.lookarounds(p), w.lookarounds(p)
+
+	var matched []int
+	pos := from
+	// the earliest offset still needed, the start of a match can't be before any of these
+	keep := func() int {
+		keepFrom := pos - p.lookBehind*utf8.UTFMax
+		if !w.keepMatch {
+			return keepFrom
+		}
+		if matched != nil {
+			keepFrom = min(keepFrom, matched[0])
+		}
+		for _, t := range current.threads {
+			if t.caps[0] >= 0 {
+				keepFrom = min(keepFrom, t.caps[0])
+			}
+		}
+		return keepFrom
+	}
+	for {
+		// the threads are added past the current character, the anchors look on from there
+		w.fill(pos, 1+p.lookAhead, keep)
+		input, at := w.text, pos-w.base
+		current.offset, next.offset = w.base, w.base
+
+		if matched == nil {
+			// nothing found so far, start a new attempt here with the lowest priority
+			current.add(p.start, input, at, p.newCaps())
+		} else if len(current.threads) == 0 {
+			break
+		}
+
+		currentChar, size := getChar(input, at)
+		next.clear()
+		for _, t := range current.threads {
+			if t.state.terminal {
+				// the threads after this one have a lower priority, drop them
+				matched = t.caps
+				break
+			}
+			if nextState := t.state.step(currentChar); nextState != nil {
+				next.add(nextState, input, at+size, t.caps)
+			}
+		}
+
+		if at >= len(input) {
+			break
+		}
+		pos += size
+		current, next = next, current
+	}
+	return matched
+}
@@ -0,0 +1,87 @@
+package goregex
+
+import "unicode"
+
+// ranges of the shorthand classes, their upper case letter stands for the complement, e.g., \D
+var perlClasses = map[rune]charset{
+	'd': {{'0', '9'}},
+	's': {{'\t', '\n'}, {'\f', '\r'}, {' ', ' '}},
+	'w': {{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}},
+}
+
+// ranges of the POSIX classes written within brackets, e.g., [[:alpha:]]
+var posixClasses = map[string]charset{
+	"alnum":  {{'0', '9'}, {'A', 'Z'}, {'a', 'z'}},
+	"alpha":  {{'A', 'Z'}, {'a', 'z'}},
+	"ascii":  {{0, 0x7f}},
+	"blank":  {{'\t', '\t'}, {' ', ' '}},
+	"cntrl":  {{0, 0x1f}, {0x7f, 0x7f}},
+	"digit":  {{'0', '9'}},
+	"graph":  {{'!', '~'}},
+	"lower":  {{'a', 'z'}},
+	"print":  {{' ', '~'}},
+	"punct":  {{'!', '/'}, {':', '@'}, {'[', '`'}, {'{', '~'}},
+	"space":  {{'\t', '\r'}, {' ', ' '}},
+	"upper":  {{'A', 'Z'}},
+	"word":   {{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}},
+	"xdigit": {{'0', '9'}, {'A', 'F'}, {'a', 'f'}},
+}
+
+// the ranges of the unicode category or script with that name, e.g., L, Lu or Greek
+func unicodeClass(name string) (charset, bool) {
+	if name == "Any" {
+		return charset{{0, unicode.MaxRune}}, true
+	}
+	table, found := unicode.Categories[name]
+	if !found {
+		table, found = unicode.Scripts[name]
+	}
+	if !found {
+		return nil, false
+	}
+	return tableRanges(table), true
+}
+
+// the code points of a table of the unicode package as a set
+func tableRanges(table *unicode.RangeTable) charset {
+	var ranges []runeRange
+	add := func(lo, hi, stride rune) {
+		if stride == 1 {
+			ranges = append(ranges, runeRange{lo: lo, hi: hi})
+			return
+		}
+		for ch := lo; ch <= hi; ch += stride {
+			ranges = append(ranges, runeRange{lo: ch, hi: ch})
+		}
+	}
+	for _, r := range table.R16 {
+		add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range table.R32 {
+		add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return newCharset(ranges...)
+}
+
+// the ranges of the shorthand class written as '\ch', false when there's no such class
+func perlClass(ch rune, foldCase bool) (charset, bool) {
+	if set, found := perlClasses[ch]; found {
+		return caseClass(set, false, foldCase), true
+	}
+	if set, found := perlClasses[unicode.ToLower(ch)]; found && unicode.IsUpper(ch) {
+		return caseClass(set, true, foldCase), true
+	}
+	return nil, false
+}
+
+// the set of a class, negated or not, taking the case insensitive flag into account.
+// the case is folded first, otherwise (?i)\W would take the upper case letters and everything with them
+func caseClass(set charset, negated bool, foldCase bool) charset {
+	if foldCase {
+		set = set.fold()
+	}
+	if negated {
+		set = set.negate()
+	}
+	return set
+}
@@ -0,0 +1,104 @@
+package goregex
+
+import "strings"
+
+// ReplaceAllString returns a copy of 'src' with every match replaced by 'repl' as is,
+// ReplaceAllTemplate is the one that expands the groups
+func (re *Regexp) ReplaceAllString(src string, repl string) string {
+	return re.replaceAll(src, func(b *strings.Builder, caps []int) {
+		b.WriteString(repl)
+	})
+}
+
+// ReplaceAllFunc returns a copy of 'src' with every match replaced by what 'repl' returns for it
+func (re *Regexp) ReplaceAllFunc(src string, repl func(string) string) string {
+	return re.replaceAll(src, func(b *strings.Builder, caps []int) {
+		b.WriteString(repl(src[caps[0]:caps[1]]))
+	})
+}
+
+// ReplaceAllTemplate returns a copy of 'src' with every match replaced by 'template',
+// where `$n` and `${n}` are replaced by the numbered group, `${name}` by the named group
+// and `$$` by a single `$`. groups that don't exist or didn't match expand to nothing,
+// a `$` that doesn't start any of these is kept as is
+func (re *Regexp) ReplaceAllTemplate(src string, template string) string {
+	return re.replaceAll(src, func(b *strings.Builder, caps []int) {
+		re.prog.expand(b, template, src, caps)
+	})
+}
+
+// copy 'src' replacing the successive non overlapping matches with what 'replace' writes
+func (re *Regexp) replaceAll(src string, replace func(b *strings.Builder, caps []int)) string {
+	var b strings.Builder
+	matched := false
+	lastMatchEnd := 0
+	allMatches(re.prog, src, -1, func(caps []int) {
+		matched = true
+		b.WriteString(src[lastMatchEnd:caps[0]])
+		replace(&b, caps)
+		lastMatchEnd = caps[1]
+	})
+	if !matched {
+		return src
+	}
+	b.WriteString(src[lastMatchEnd:])
+	return b.String()
+}
+
+// write 'template' with its group references replaced by what the groups captured in 'src'
+func (p *program) expand(b *strings.Builder, template string, src string, caps []int) {
+	for len(template) > 0 {
+		dollar := strings.IndexByte(template, '$')
+		if dollar == -1 {
+			b.WriteString(template)
+			return
+		}
+		b.WriteString(template[:dollar])
+		template = template[dollar:]
+
+		name, rest, ok := templateReference(template)
+		if !ok {
+			// not a reference, the dollar is written as is
+			b.WriteByte('$')
+			template = template[1:]
+			continue
+		}
+		template = rest
+		if name == "$" {
+			b.WriteByte('$')
+			continue
+		}
+		index, found := p.groupIndex[name]
+		if !found {
+			continue
+		}
+		if start, end := caps[2*index], caps[2*index+1]; start >= 0 && end >= start {
+			b.WriteString(src[start:end])
+		}
+	}
+}
+
+// parse the reference at the start of 'template', which starts with a '$'.
+// returns the group name ("$" for an escaped dollar) and what follows the reference
+func templateReference(template string) (name string, rest string, ok bool) {
+	if len(template) < 2 {
+		return "", template, false
+	}
+	switch {
+	case template[1] == '$':
+		return "$", template[2:], true
+	case template[1] == '{':
+		closing := strings.IndexByte(template, '}')
+		if closing <= 2 {
+			return "", template, false
+		}
+		return template[2:closing], template[closing+1:], true
+	case isDig(rune(template[1])):
+		end := 2
+		for end < len(template) && isDig(rune(template[end])) {
+			end++
+		}
+		return template[1:end], template[end:], true
+	}
+	return "", template, false
+}
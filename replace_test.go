@@ -0,0 +1,51 @@
+package goregex
+
+import "testing"
+
+func TestReplaceAllTemplate(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		src      string
+		template string
+		want     string
+	}{
+		{`(\w+)@(\w+)`, "me@home, you@work", "$2:$1", "home:me, work:you"},
+		{`(\w+)@(\w+)`, "me@home", "${2}x$1", "homexme"},
+		{`(?<user>\w+)@(?<host>\w+)`, "me@home", "${host}/${user}", "home/me"},
+		{`(\w+)`, "price", "$$$1", "$price"},
+		{`(\w+)`, "a b", "<$0>", "<a> <b>"},
+		// groups that don't exist or didn't match are empty
+		{`(a)|(b)`, "ab", "[$1$2$3${none}]", "[a][b]"},
+		// a dollar that doesn't start a reference stays
+		{`x`, "x", "$ $a ${", "$ $a ${"},
+		{`x`, "x", "cost: $", "cost: $"},
+		// the longest number is taken
+		{`(a)`, "a", "$10", ""},
+		{`(a)`, "a", "${1}0", "a0"},
+		{`x`, "abc", "$1", "abc"},
+		{`b*`, "abc", "-", "-a-c-"},
+	}
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+		}
+		if got := re.ReplaceAllTemplate(test.src, test.template); got != test.want {
+			t.Errorf("%q.ReplaceAllTemplate(%q, %q) = %q, want %q", test.pattern, test.src, test.template, got, test.want)
+		}
+	}
+}
+
+func TestReplaceAllString(t *testing.T) {
+	re, err := Compile(`(\w+)`)
+	if err != nil {
+		t.Fatalf("Compile: %s", err.Render())
+	}
+	// the replacement is written as is, the template syntax isn't expanded
+	if got, want := re.ReplaceAllString("a b", "$1"), "$1 $1"; got != want {
+		t.Errorf("ReplaceAllString = %q, want %q", got, want)
+	}
+	if got, want := re.ReplaceAllFunc("a bc", func(s string) string { return s + s }), "aa bcbc"; got != want {
+		t.Errorf("ReplaceAllFunc = %q, want %q", got, want)
+	}
+}
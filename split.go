@@ -0,0 +1,124 @@
+package goregex
+
+// Split slices 'inputString' around the matches of the pattern and returns what's in between,
+// the pieces are the same as regexp.Split's in the standard library. 'n' > 0 returns at most 'n' pieces,
+// the last one being the rest of the input as is, 'n' == 0 returns nil and 'n' < 0 returns every piece.
+// an empty match at the very start or end of the input doesn't make an empty piece there
+func (re *Regexp) Split(inputString string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	if len(inputString) == 0 {
+		// a single empty piece, unless the pattern is empty too
+		if len(re.expr) == 0 {
+			return []string{}
+		}
+		return []string{""}
+	}
+
+	var pieces []string
+	cursor := newMatchCursor(re.prog, inputString)
+	pieceStart, lastCut := 0, 0
+	for n < 0 || len(pieces) < n-1 {
+		caps := cursor.next()
+		if caps == nil {
+			break
+		}
+		lastCut = caps[0]
+		if caps[1] == 0 {
+			continue
+		}
+		pieces = append(pieces, inputString[pieceStart:caps[0]])
+		pieceStart = caps[1]
+	}
+	if lastCut < len(inputString) {
+		pieces = append(pieces, inputString[pieceStart:])
+	}
+	return pieces
+}
+
+// Token is a piece of the input handed out by a Tokenizer,
+// either a match or the text in between two matches
+type Token struct {
+	Text    string
+	Start   int // byte offset of the token in the input
+	End     int
+	IsMatch bool
+	Groups  map[string]string // groups of the match, nil for the text in between
+}
+
+// Tokenizer goes through the input from left to right, yielding the text in between the
+// matches and the matches themselves in turn. empty stretches of text are skipped,
+// so two matches can come one after the other
+type Tokenizer struct {
+	re      *Regexp
+	cursor  *matchCursor[string]
+	lastEnd int   // end of the last token handed out
+	pending []int // a match waiting for the text before it to be handed out
+	done    bool
+	token   Token
+}
+
+// Tokenize returns a Tokenizer over 'inputString', call Next before reading each Token
+func (re *Regexp) Tokenize(inputString string) *Tokenizer {
+	return &Tokenizer{
+		re:     re,
+		cursor: newMatchCursor(re.prog, inputString),
+	}
+}
+
+// Next moves to the next token, false once the input is exhausted
+func (t *Tokenizer) Next() bool {
+	if t.pending != nil {
+		t.setMatch(t.pending)
+		t.pending = nil
+		return true
+	}
+	if t.done {
+		return false
+	}
+
+	input := t.cursor.window.text
+	caps := t.cursor.next()
+	if caps == nil {
+		t.done = true
+		if t.lastEnd < len(input) {
+			t.setText(len(input))
+			return true
+		}
+		return false
+	}
+
+	if caps[0] > t.lastEnd {
+		t.setText(caps[0])
+		t.pending = caps
+		return true
+	}
+	t.setMatch(caps)
+	return true
+}
+
+// Token returns the current token
+func (t *Tokenizer) Token() Token {
+	return t.token
+}
+
+func (t *Tokenizer) setText(end int) {
+	t.token = Token{
+		Text:  t.cursor.window.text[t.lastEnd:end],
+		Start: t.lastEnd,
+		End:   end,
+	}
+	t.lastEnd = end
+}
+
+func (t *Tokenizer) setMatch(caps []int) {
+	t.token = Token{
+		Text:    t.cursor.window.text[caps[0]:caps[1]],
+		Start:   caps[0],
+		End:     caps[1],
+		IsMatch: true,
+		Groups:  t.re.prog.result(t.cursor.window.text, caps).Groups,
+	}
+	t.lastEnd = caps[1]
+}
@@ -0,0 +1,108 @@
+package goregex
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestSplitAgainstRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+	}{
+		{`,`, "a,b,c"},
+		{`,`, "a,b,"},
+		{`,`, ",a,,b"},
+		{`,`, ""},
+		{``, ""},
+		{``, "abc"},
+		{`x*`, "axbxxc"},
+		{`x*`, "xax"},
+		{`a*`, "baaac"},
+		{`\s+`, "  one two\tthree  "},
+		{`é`, "aébéc"},
+		{`(?:)`, "日本"},
+		{`$`, "ab"},
+		{`^`, "ab"},
+	}
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+		}
+		want := regexp.MustCompile(test.pattern)
+		for _, n := range []int{-1, 0, 1, 2, 3} {
+			if got, want := re.Split(test.input, n), want.Split(test.input, n); !reflect.DeepEqual(got, want) {
+				t.Errorf("%q.Split(%q, %d) = %q, want %q", test.pattern, test.input, n, got, want)
+			}
+		}
+	}
+}
+
+func TestTokenizer(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		want    []Token
+	}{
+		{`\d+`, "ab12cd3", []Token{
+			{Text: "ab", Start: 0, End: 2},
+			{Text: "12", Start: 2, End: 4, IsMatch: true},
+			{Text: "cd", Start: 4, End: 6},
+			{Text: "3", Start: 6, End: 7, IsMatch: true},
+		}},
+		// no empty text in between two matches or at the ends
+		{`\d`, "12a", []Token{
+			{Text: "1", Start: 0, End: 1, IsMatch: true},
+			{Text: "2", Start: 1, End: 2, IsMatch: true},
+			{Text: "a", Start: 2, End: 3},
+		}},
+		{`x`, "abc", []Token{{Text: "abc", Start: 0, End: 3}}},
+		{`x`, "", nil},
+		{`b*`, "abc", []Token{
+			{Text: "", Start: 0, End: 0, IsMatch: true},
+			{Text: "a", Start: 0, End: 1},
+			{Text: "b", Start: 1, End: 2, IsMatch: true},
+			{Text: "c", Start: 2, End: 3},
+			{Text: "", Start: 3, End: 3, IsMatch: true},
+		}},
+	}
+	for _, test := range tests {
+		re, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %s", test.pattern, err.Render())
+		}
+		var got []Token
+		for tokenizer := re.Tokenize(test.input); tokenizer.Next(); {
+			token := tokenizer.Token()
+			token.Groups = nil
+			got = append(got, token)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q.Tokenize(%q) = %+v, want %+v", test.pattern, test.input, got, test.want)
+		}
+	}
+}
+
+func TestTokenizerGroups(t *testing.T) {
+	re, err := Compile(`(?<key>\w+)=(?<value>\w*)`)
+	if err != nil {
+		t.Fatalf("Compile: %s", err.Render())
+	}
+	tokenizer := re.Tokenize("a=1; b=")
+	var matches []map[string]string
+	for tokenizer.Next() {
+		token := tokenizer.Token()
+		if !token.IsMatch {
+			if token.Groups != nil {
+				t.Errorf("text %q has groups %v", token.Text, token.Groups)
+			}
+			continue
+		}
+		matches = append(matches, map[string]string{"key": token.Groups["key"], "value": token.Groups["value"]})
+	}
+	if want := []map[string]string{{"key": "a", "value": "1"}, {"key": "b", "value": ""}}; !reflect.DeepEqual(matches, want) {
+		t.Errorf("groups = %v, want %v", matches, want)
+	}
+}
@@ -0,0 +1,279 @@
+package goregex
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// bytes asked from an io.Reader at once, also how much has to be read before
+// the window looks for text to drop
+const streamChunk = 4096
+
+// the part of the input the matchers can see, the text from the byte offset 'base' on.
+// an input in memory is seen whole, a reader is read as the matchers get further
+// and the text nothing can reach anymore is dropped
+type inputWindow[T inputText] struct {
+	text      T
+	base      int
+	eof       bool // whether 'text' runs until the end of the input
+	keepMatch bool // whether the text of the match being looked for has to stay, e.g., for Scanner.Text
+	stream    *readerStream
+	looks     *lookaroundCache // what's known about the lookarounds over the input, nil until asked for
+	failures  *failureMemo     // where the backtracking matcher failed, nil until asked for
+}
+
+func newWindow[T inputText](input T) *inputWindow[T] {
+	return &inputWindow[T]{
+		text: input,
+		eof:  true,
+	}
+}
+
+func newStreamWindow(stream *readerStream, keepMatch bool) *inputWindow[string] {
+	return &inputWindow[string]{
+		keepMatch: keepMatch,
+		stream:    stream,
+	}
+}
+
+// read until the window holds 'ahead' whole characters from the byte offset 'pos' on or the
+// input ends, dropping the text before the offset 'keep' returns when there's enough of it.
+// 'keep' can be nil
+func (w *inputWindow[T]) fill(pos int, ahead int, keep func() int) {
+	if w.eof {
+		return
+	}
+	w.stream.fill(pos, ahead, keep)
+	w.sync()
+}
+
+// read the rest of the input
+func (w *inputWindow[T]) readAll() {
+	if w.eof {
+		return
+	}
+	w.stream.readAll()
+	w.sync()
+}
+
+// the lookaround cache of the window, nil when the pattern has no lookaround
+// or they're matched by the backtracking matcher
+func (w *inputWindow[T]) lookarounds(p *program) *lookaroundCache {
+	if !p.lookarounds || p.needsBacktrack {
+		return nil
+	}
+	if w.looks == nil {
+		w.looks = newLookaroundCache(len(p.states))
+	}
+	return w.looks
+}
+
+// the failure memo of the backtracking matcher over the input, nil when the pattern has backreferences
+func (w *inputWindow[T]) backtrackFailures(p *program) *failureMemo {
+	if p.hasBackrefs {
+		return nil
+	}
+	if w.failures == nil {
+		w.failures = newFailureMemo(len(p.states), len(w.text))
+	}
+	return w.failures
+}
+
+// take what the stream holds, only string windows are made over a stream
+// so the conversion never copies anything
+func (w *inputWindow[T]) sync() {
+	w.text, w.base, w.eof = T(w.stream.text), w.stream.base, w.stream.eof
+}
+
+// the text read from a reader that's still needed, from the byte offset 'base' on
+type readerStream struct {
+	text      string
+	base      int
+	eof       bool
+	err       error
+	buf       strings.Builder // holds 'text', a builder hands out its content without copying it
+	bufBase   int             // byte offset of the start of 'buf' within the input
+	sinceTrim int             // bytes read since the last look for text to drop
+
+	reader     io.Reader // read a chunk at a time
+	chunk      []byte
+	runeReader io.RuneReader // read a character at a time so that nothing is taken from it past what's needed
+}
+
+func (s *readerStream) fill(pos int, ahead int, keep func() int) {
+	for !s.eof && !s.holds(pos, ahead) {
+		s.readMore()
+	}
+	if keep != nil && s.sinceTrim >= streamChunk {
+		s.sinceTrim = 0
+		s.trim(keep())
+	}
+}
+
+func (s *readerStream) readAll() {
+	for !s.eof {
+		s.readMore()
+	}
+}
+
+func (s *readerStream) readMore() {
+	before := s.buf.Len()
+	var err error
+	if s.runeReader != nil {
+		var ch rune
+		var size int
+		ch, size, err = s.runeReader.ReadRune()
+		if err == nil && ch == utf8.RuneError && size == 1 {
+			// an invalid byte is kept as one, so that the offsets add up to what the reader went through
+			s.buf.WriteByte(0xff)
+		} else if err == nil {
+			s.buf.WriteRune(ch)
+		}
+	} else {
+		var n int
+		n, err = s.reader.Read(s.chunk)
+		s.buf.Write(s.chunk[:n])
+	}
+	s.sinceTrim += s.buf.Len() - before
+	s.text = s.buf.String()[s.base-s.bufBase:]
+	if err != nil {
+		s.eof = true
+		if err != io.EOF {
+			s.err = err
+		}
+	}
+}
+
+// whether the stream holds 'n' whole characters from the byte offset 'pos' on,
+// a reader can stop in the middle of one
+func (s *readerStream) holds(pos int, n int) bool {
+	rest := s.text[min(pos-s.base, len(s.text)):]
+	for ; n > 0; n-- {
+		if !utf8.FullRuneInString(rest) {
+			return false
+		}
+		_, size := utf8.DecodeRuneInString(rest)
+		rest = rest[size:]
+	}
+	return true
+}
+
+// forget the text before the byte offset 'keepFrom', the builder is only replaced
+// when most of it would go, so that the text left is copied once in a while
+func (s *readerStream) trim(keepFrom int) {
+	if keepFrom <= s.base {
+		return
+	}
+	keepFrom = min(keepFrom, s.base+len(s.text))
+	s.text = s.text[keepFrom-s.base:]
+	s.base = keepFrom
+	if dropped := s.base - s.bufBase; dropped >= streamChunk && dropped >= s.buf.Len()/2 {
+		s.buf = strings.Builder{}
+		s.buf.WriteString(s.text)
+		s.bufBase = s.base
+		s.text = s.buf.String()
+	}
+}
+
+// whether the pike vm can go through the input piece by piece, otherwise the matchers
+// need all of it: the backtracking engine can come back to anywhere and an unbounded
+// lookahead can look until the end
+func (p *program) streams() bool {
+	return !p.needsBacktrack && p.engine != EngineBacktrack && p.lookAhead != quantInfinity
+}
+
+// the capture slots of the leftmost match at or after the byte offset 'from',
+// whatever the window covers or the matcher needs is read first
+func matchWindow[T inputText](p *program, w *inputWindow[T], from int) []int {
+	if !p.streams() {
+		w.readAll()
+	}
+	if w.eof && w.base == 0 {
+		return matchWhole(p, w, from)
+	}
+	return pikeRun(p, w, from)
+}
+
+// MatchReader reports whether the text read from 'r' has a match, reading only a few characters
+// past the end of the match. patterns with backreferences, atomic groups, possessive quantifiers
+// or a lookahead without a bounded length need the whole text and read it all first
+func (re *Regexp) MatchReader(r io.RuneReader) bool {
+	return re.FindReaderIndex(r) != nil
+}
+
+// FindReaderIndex returns the start and end byte offsets of the leftmost match in the text
+// read from 'r', nil when there's none. only what the pattern can look back at is kept in memory
+func (re *Regexp) FindReaderIndex(r io.RuneReader) []int {
+	caps := matchWindow(re.prog, newStreamWindow(&readerStream{runeReader: r}, false), 0)
+	if caps == nil {
+		return nil
+	}
+	return caps[0:2]
+}
+
+// FindReaderSubmatchIndex is FindReaderIndex with the offsets of the groups, laid out as in FindSubmatchIndex
+func (re *Regexp) FindReaderSubmatchIndex(r io.RuneReader) []int {
+	return matchWindow(re.prog, newStreamWindow(&readerStream{runeReader: r}, false), 0)
+}
+
+// Scanner goes through the text of an io.Reader and hands out the successive non overlapping
+// matches, the same ones as FindAllIndex would. the input is read a chunk at a time and only
+// the text of the match being looked at, along with what the pattern can look back at, is kept
+type Scanner struct {
+	prog   *program
+	cursor *matchCursor[string]
+	caps   []int
+	text   string // the input from the byte offset 'base' on, as it was when the match was found
+	base   int
+}
+
+// NewScanner returns a Scanner over 'r', call Next before reading each match
+func (re *Regexp) NewScanner(r io.Reader) *Scanner {
+	stream := &readerStream{
+		reader: r,
+		chunk:  make([]byte, streamChunk),
+	}
+	return &Scanner{
+		prog:   re.prog,
+		cursor: newWindowCursor(re.prog, newStreamWindow(stream, true)),
+	}
+}
+
+// Next moves to the next match, false once there are no more or reading failed
+func (s *Scanner) Next() bool {
+	s.caps = s.cursor.next()
+	if s.caps == nil {
+		return false
+	}
+	s.text, s.base = s.cursor.window.text, s.cursor.window.base
+	return true
+}
+
+// Index returns the byte offsets of the current match and its groups within the whole input,
+// laid out as in FindSubmatchIndex
+func (s *Scanner) Index() []int {
+	return append([]int(nil), s.caps...)
+}
+
+// Text returns the text of the current match
+func (s *Scanner) Text() string {
+	return s.text[s.caps[0]-s.base : s.caps[1]-s.base]
+}
+
+// Result returns the current match along with the text of its groups
+func (s *Scanner) Result() Result {
+	caps := make([]int, len(s.caps))
+	for i, offset := range s.caps {
+		caps[i] = offset
+		if offset >= 0 {
+			caps[i] -= s.base
+		}
+	}
+	return s.prog.result(s.text, caps)
+}
+
+// Err returns the first error other than io.EOF the reader returned
+func (s *Scanner) Err() error {
+	return s.cursor.window.stream.err
+}